@@ -0,0 +1,65 @@
+package retryable
+
+import "context"
+
+// Result holds the final value or error of a retry loop run in the background.
+type Result[T any] struct {
+	Value T
+	Err   error
+}
+
+// RetryChan runs the retry loop of RetryWithContext in a new goroutine and returns a channel on which the
+// final Result is sent once, before the channel is closed. The channel is buffered, so the goroutine
+// never leaks, even if the result is never received; canceling the context stops the loop promptly.
+func RetryChan[T any](ctx context.Context, fn func() (T, error), opts ...Option) <-chan Result[T] {
+	results := make(chan Result[T], 1)
+	go func() {
+		defer close(results)
+		value, err := RetryWithContext(ctx, ignoreContext(fn), opts...)
+		results <- Result[T]{Value: value, Err: err}
+	}()
+	return results
+}
+
+// RetryFuture is a handle on a retry loop started by RetryAsync, to collect its result later or to
+// cancel it. It is safe for concurrent use.
+type RetryFuture[T any] struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+	result Result[T]
+}
+
+// RetryAsync starts the retry loop of RetryWithContext in a new goroutine and returns a RetryFuture to
+// collect its result. The goroutine finishes as soon as the loop does, whether or not the result is
+// ever collected, and canceling the parent context or calling Cancel stops the loop promptly.
+func RetryAsync[T any](ctx context.Context, fn func(context.Context) (T, error), opts ...Option) *RetryFuture[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	f := &RetryFuture[T]{cancel: cancel, done: make(chan struct{})}
+	go func() {
+		defer close(f.done)
+		defer cancel()
+		value, err := RetryWithContext(ctx, fn, opts...)
+		f.result = Result[T]{Value: value, Err: err}
+	}()
+	return f
+}
+
+// Result blocks until the loop finishes and returns its final value and error. After Cancel, the error
+// is a *RetryError with OutcomeCanceled wrapping context.Canceled, unless the loop had already finished.
+// It can be called several times and always returns the same result.
+func (f *RetryFuture[T]) Result() (T, error) {
+	<-f.done
+	return f.result.Value, f.result.Err
+}
+
+// Cancel stops the loop: a wait between attempts ends immediately and no new attempt is started, while
+// the context of an attempt in progress is canceled. It does not wait for the loop to finish; call
+// Result for that. Calling Cancel after the loop finished, or several times, has no effect.
+func (f *RetryFuture[T]) Cancel() {
+	f.cancel()
+}
+
+// Done returns a channel closed when the loop finishes, to select on the result along other events.
+func (f *RetryFuture[T]) Done() <-chan struct{} {
+	return f.done
+}
@@ -0,0 +1,100 @@
+package retryable_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestRetryChan tests that the final result is streamed once and the channel is closed.
+func TestRetryChan(t *testing.T) {
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		if attempts < 2 {
+			return 0, errors.New("temporary error")
+		}
+		return attempts, nil
+	}
+
+	results := retryable.RetryChan(context.Background(), fn, retryable.WithDelay(1*time.Millisecond))
+	select {
+	case res := <-results:
+		if res.Err != nil || res.Value != 2 {
+			t.Errorf("Expected success on the second attempt, got %+v", res)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatalf("Timed out waiting for the result")
+	}
+	if _, ok := <-results; ok {
+		t.Errorf("Expected the channel to be closed after the result")
+	}
+}
+
+// TestRetryChanCanceled tests that canceling the context ends the background loop with the context error.
+func TestRetryChanCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	fn := func() (int, error) {
+		return 0, errors.New("down")
+	}
+
+	results := retryable.RetryChan(ctx, fn, retryable.WithMaxAttempts(100), retryable.WithDelay(1*time.Hour))
+	cancel()
+
+	select {
+	case res := <-results:
+		if !errors.Is(res.Err, context.Canceled) {
+			t.Errorf("Expected a canceled error, got %v", res.Err)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatalf("Timed out waiting for the canceled result")
+	}
+}
+
+// TestRetryAsync tests that the result of a background loop can be collected later, several times.
+func TestRetryAsync(t *testing.T) {
+	attempts := 0
+	future := retryable.RetryAsync(context.Background(), func(context.Context) (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, errors.New("temporary error")
+		}
+		return attempts, nil
+	}, retryable.WithDelay(1*time.Millisecond))
+
+	for i := 0; i < 2; i++ {
+		value, err := future.Result()
+		if err != nil || value != 3 {
+			t.Errorf("Expected success on the third attempt, got %d with error %v", value, err)
+		}
+	}
+}
+
+// TestRetryAsyncCancel tests that Cancel stops the loop promptly and that Result reports the cancellation.
+func TestRetryAsyncCancel(t *testing.T) {
+	started := make(chan struct{}, 1)
+	future := retryable.RetryAsync(context.Background(), func(context.Context) (int, error) {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		return 0, errors.New("down")
+	}, retryable.WithMaxAttempts(100), retryable.WithDelay(1*time.Hour))
+
+	<-started
+	future.Cancel()
+	select {
+	case <-future.Done():
+	case <-time.After(1 * time.Second):
+		t.Fatal("Expected the loop to stop promptly after Cancel")
+	}
+
+	_, err := future.Result()
+	var retryErr *retryable.RetryError
+	if !errors.As(err, &retryErr) || retryErr.Outcome != retryable.OutcomeCanceled || !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected a canceled *RetryError, got %v", err)
+	}
+}
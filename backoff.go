@@ -0,0 +1,377 @@
+package retryable
+
+import (
+	"errors"
+	"math"
+	"math/rand/v2"
+	"time"
+)
+
+// BackoffStrategy computes how long to wait before retrying.
+type BackoffStrategy interface {
+	// Delay returns the delay to wait after the given failed attempt (1-based) before the next one.
+	Delay(attempt int) time.Duration
+}
+
+// BackoffFunc adapts an ordinary function to the BackoffStrategy interface.
+type BackoffFunc func(attempt int) time.Duration
+
+// Delay implements BackoffStrategy.
+func (f BackoffFunc) Delay(attempt int) time.Duration {
+	return f(attempt)
+}
+
+// ConstantBackoff returns a strategy that always waits the same delay.
+func ConstantBackoff(delay time.Duration) BackoffStrategy {
+	return constantBackoff(delay)
+}
+
+// constantBackoff is the strategy returned by ConstantBackoff.
+type constantBackoff time.Duration
+
+// Delay implements BackoffStrategy.
+func (b constantBackoff) Delay(int) time.Duration {
+	return time.Duration(b)
+}
+
+// validate implements validator.
+func (b constantBackoff) validate() error {
+	if b < 0 {
+		return invalidConfig("delay must not be negative, got %v", time.Duration(b))
+	}
+	return nil
+}
+
+// ExponentialBackoff returns a strategy that waits base after the first failed attempt and multiplies
+// the delay by multiplier after each subsequent one, never exceeding max. A max of zero means no cap.
+func ExponentialBackoff(base time.Duration, multiplier float64, max time.Duration) BackoffStrategy {
+	return exponentialBackoff{base: base, multiplier: multiplier, max: max}
+}
+
+// exponentialBackoff is the strategy returned by ExponentialBackoff.
+type exponentialBackoff struct {
+	base       time.Duration
+	multiplier float64
+	max        time.Duration
+}
+
+// Delay implements BackoffStrategy.
+func (b exponentialBackoff) Delay(attempt int) time.Duration {
+	delay := float64(b.base) * math.Pow(b.multiplier, float64(attempt-1))
+	if b.max > 0 && delay > float64(b.max) {
+		return b.max
+	}
+	if delay > math.MaxInt64 {
+		return time.Duration(math.MaxInt64)
+	}
+	return time.Duration(delay)
+}
+
+// validate implements validator.
+func (b exponentialBackoff) validate() error {
+	var errs []error
+	if b.base < 0 {
+		errs = append(errs, invalidConfig("base delay must not be negative, got %v", b.base))
+	}
+	if b.multiplier < 1 {
+		errs = append(errs, invalidConfig("backoff multiplier must be at least 1, got %v", b.multiplier))
+	}
+	if b.max > 0 && b.base > b.max {
+		errs = append(errs, invalidConfig("base delay %v is greater than max delay %v", b.base, b.max))
+	}
+	return errors.Join(errs...)
+}
+
+// TieredBackoff returns a two-phase strategy: the first fastRetries retries wait fastDelay, to catch quick
+// blips, and the following ones are delegated to then, starting from its first delay. With a fastRetries
+// of 2, retries 1 and 2 wait fastDelay and retry 3 waits then.Delay(1).
+func TieredBackoff(fastDelay time.Duration, fastRetries int, then BackoffStrategy) BackoffStrategy {
+	return tieredBackoff{fastDelay: fastDelay, fastRetries: fastRetries, then: then}
+}
+
+// tieredBackoff is the strategy returned by TieredBackoff.
+type tieredBackoff struct {
+	fastDelay   time.Duration
+	fastRetries int
+	then        BackoffStrategy
+}
+
+// Delay implements BackoffStrategy.
+func (b tieredBackoff) Delay(attempt int) time.Duration {
+	if attempt <= b.fastRetries {
+		return b.fastDelay
+	}
+	return b.then.Delay(attempt - b.fastRetries)
+}
+
+// validate implements validator.
+func (b tieredBackoff) validate() error {
+	var errs []error
+	if b.fastDelay < 0 {
+		errs = append(errs, invalidConfig("fast delay must not be negative, got %v", b.fastDelay))
+	}
+	if b.fastRetries < 0 {
+		errs = append(errs, invalidConfig("fast retries must not be negative, got %d", b.fastRetries))
+	}
+	if v, ok := b.then.(validator); ok {
+		errs = append(errs, v.validate())
+	}
+	return errors.Join(errs...)
+}
+
+// RampBackoff returns a strategy growing geometrically from base after the first failed attempt to cap
+// after the given failed attempt, then waiting cap. It expects 0 < base < cap and attempts > 1.
+func RampBackoff(base, cap time.Duration, attempts int) BackoffStrategy {
+	b := rampBackoff{base: base, cap: cap, attempts: attempts}
+	if attempts > 1 && base > 0 {
+		b.multiplier = math.Pow(float64(cap)/float64(base), 1/float64(attempts-1))
+	}
+	return b
+}
+
+// rampBackoff is the strategy returned by RampBackoff.
+type rampBackoff struct {
+	base       time.Duration
+	cap        time.Duration
+	attempts   int
+	multiplier float64
+}
+
+// Delay implements BackoffStrategy.
+func (b rampBackoff) Delay(attempt int) time.Duration {
+	if attempt >= b.attempts {
+		// The geometric progression may fall a few nanoseconds short of cap due to rounding.
+		return b.cap
+	}
+	return min(time.Duration(float64(b.base)*math.Pow(b.multiplier, float64(attempt-1))), b.cap)
+}
+
+// validate implements validator.
+func (b rampBackoff) validate() error {
+	var errs []error
+	if b.base <= 0 || b.base >= b.cap {
+		errs = append(errs, invalidConfig("ramp needs 0 < base < cap, got base %v and cap %v", b.base, b.cap))
+	}
+	if b.attempts < 2 {
+		errs = append(errs, invalidConfig("ramp attempts must be greater than 1, got %d", b.attempts))
+	}
+	return errors.Join(errs...)
+}
+
+// LoadAwareBackoff returns a strategy waiting base plus the fraction of the range up to max given by
+// pressure, read before each wait and clamped to [0, 1], instead of depending on the attempt number.
+func LoadAwareBackoff(pressure func() float64, base, max time.Duration) BackoffStrategy {
+	return loadAwareBackoff{pressure: pressure, base: base, max: max}
+}
+
+// loadAwareBackoff is the strategy returned by LoadAwareBackoff.
+type loadAwareBackoff struct {
+	pressure func() float64
+	base     time.Duration
+	max      time.Duration
+}
+
+// Delay implements BackoffStrategy.
+func (b loadAwareBackoff) Delay(int) time.Duration {
+	p := b.pressure()
+	if math.IsNaN(p) {
+		p = 0
+	}
+	p = min(max(p, 0), 1)
+	return b.base + time.Duration(p*float64(b.max-b.base))
+}
+
+// validate implements validator.
+func (b loadAwareBackoff) validate() error {
+	if b.base < 0 || b.base > b.max {
+		return invalidConfig("load-aware bounds must satisfy 0 <= base <= max, got base %v and max %v", b.base, b.max)
+	}
+	return nil
+}
+
+// BackoffHinter is implemented by errors carrying scheduling advice, such as a Retry-After header: the
+// option-based loop waits a positive delay instead of that of the strategy, and restarts the progression of
+// the strategy, as well as the streak of WithStatefulBackoff, when reset is true.
+type BackoffHinter interface {
+	BackoffHint() (delay time.Duration, reset bool)
+}
+
+// backoffHint returns the advice of the first error implementing BackoffHinter in the chain of err, if any.
+func backoffHint(err error) (time.Duration, bool) {
+	var hinter BackoffHinter
+	if !errors.As(err, &hinter) {
+		return 0, false
+	}
+	return hinter.BackoffHint()
+}
+
+// WithBackoff sets the strategy computing the delay between attempts.
+// WithBackoff, WithDelay, WithConstantBackoff and WithExponentialBackoff all replace the strategy in
+// effect, so the last one given wins, while WithJitter applies on top of whichever strategy wins,
+// regardless of its position in the list of options.
+func WithBackoff(strategy BackoffStrategy) Option {
+	return func(c *config) {
+		c.backoff = strategy
+	}
+}
+
+// WithConstantBackoff waits the same delay between attempts, see ConstantBackoff.
+func WithConstantBackoff(delay time.Duration) Option {
+	return WithBackoff(ConstantBackoff(delay))
+}
+
+// WithExponentialBackoff waits exponentially growing delays between attempts, see ExponentialBackoff.
+func WithExponentialBackoff(base time.Duration, multiplier float64, max time.Duration) Option {
+	return WithBackoff(ExponentialBackoff(base, multiplier, max))
+}
+
+// WithTieredBackoff waits fastDelay for the first fastRetries retries, then follows the given strategy,
+// see TieredBackoff.
+func WithTieredBackoff(fastDelay time.Duration, fastRetries int, then BackoffStrategy) Option {
+	return WithBackoff(TieredBackoff(fastDelay, fastRetries, then))
+}
+
+// WithBackoffRampTo waits with a delay growing geometrically from base to cap, reached after the given
+// failed attempt, see RampBackoff.
+func WithBackoffRampTo(base, cap time.Duration, attempts int) Option {
+	return WithBackoff(RampBackoff(base, cap, attempts))
+}
+
+// WithLoadAwareBackoff waits with a delay within [base, max] scaled by the current reading of pressure,
+// called once before each wait, see LoadAwareBackoff.
+func WithLoadAwareBackoff(pressure func() float64, base, max time.Duration) Option {
+	return WithBackoff(LoadAwareBackoff(pressure, base, max))
+}
+
+// WithJitter randomizes each delay computed by the backoff strategy by up to factor times the delay,
+// in both directions, so that clients failing together do not retry in lockstep. A factor of 0.2 turns
+// a 1s delay into a delay between 800ms and 1.2s. The factor is expected between 0 and 1.
+func WithJitter(factor float64) Option {
+	return func(c *config) {
+		c.jitter = factor
+	}
+}
+
+// WithMonotonicJitter makes the jitter of WithJitter only lengthen the delays, adding up to factor times
+// the delay and never subtracting, and keeps each delay at least as long as the previous one of the same
+// call, so the sequence of delays never decreases while clients are still de-synchronized. Delays hinted
+// by a BackoffHinter error are not affected.
+func WithMonotonicJitter() Option {
+	return func(c *config) {
+		c.monotonicJitter = true
+	}
+}
+
+// WithDelayModifier sets a function applied to every delay, hinted ones included, as the last step before
+// waiting, the result being checked against the budget and the deadline. A negative result counts as zero.
+func WithDelayModifier(modify func(base time.Duration) time.Duration) Option {
+	return func(c *config) {
+		c.delayModifier = modify
+	}
+}
+
+// AttemptRecord describes a failed attempt, as seen by the function of WithHistoryAwareDelay.
+type AttemptRecord struct {
+	// Attempt is the number of the attempt, starting at 1.
+	Attempt int
+
+	// Err is the error returned by the attempt.
+	Err error
+
+	// Duration is the time the attempt took.
+	Duration time.Duration
+}
+
+// WithHistoryAwareDelay computes every delay with the given function, from the records of the failed
+// attempts of the call so far, instead of the backoff strategy, its jitter and growth cap. The function
+// must not modify the slice, which grows by one record per failed attempt.
+func WithHistoryAwareDelay(delay func(history []AttemptRecord) time.Duration) Option {
+	return func(c *config) {
+		c.historyDelay = delay
+	}
+}
+
+// WithFirstRetryImmediate makes the first retry of each call immediate, to catch a quick blip, while the
+// later retries wait as the backoff strategy says: the delay after the second failed attempt is still the
+// strategy's Delay(2), not its Delay(1), and it is neither jittered nor capped by WithMaxGrowthFactor relative to
+// the zero delay. A delay hinted by a BackoffHinter error after the first attempt is still honored.
+func WithFirstRetryImmediate() Option {
+	return func(c *config) {
+		c.firstRetryImmediate = true
+	}
+}
+
+// WithMaxGrowthFactor limits how much the delay may grow from one retry to the next: each delay is capped
+// at factor times the previous delay of the same call, after jitter is applied, so that aggressive or
+// spiky strategies follow a smooth curve. The first delay is not capped, and delays may always shrink.
+// The factor is expected to be at least 1.
+func WithMaxGrowthFactor(factor float64) Option {
+	return func(c *config) {
+		if factor < 1 {
+			c.invalid("max growth factor must be at least 1, got %v", factor)
+		}
+		c.maxGrowthFactor = factor
+	}
+}
+
+// WithDelayRounding rounds each delay to the nearest multiple of unit, such as 100ms or 1s, so that logs
+// and schedules show human-friendly values. The rounding is applied last, after jitter and the growth cap,
+// so the delay slept is the one logged; a delay shorter than half the unit rounds to zero.
+// The unit must be positive.
+func WithDelayRounding(unit time.Duration) Option {
+	return func(c *config) {
+		if unit <= 0 {
+			c.invalid("delay rounding unit must be positive, got %v", unit)
+		}
+		c.delayRounding = unit
+	}
+}
+
+// WithRandSource sets the source of randomness used for jitter, and for the failures drawn by
+// SimulateDistribution, instead of the global source of math/rand/v2, e.g. a seeded source for
+// reproducible delays. Sources are usually not safe for concurrent use, so loops running concurrently
+// should not share one.
+func WithRandSource(src rand.Source) Option {
+	return func(c *config) {
+		c.rand = rand.New(src)
+	}
+}
+
+// randFloat returns a random number within [0, 1) from the configured source.
+func (c *config) randFloat() float64 {
+	if c.rand != nil {
+		return c.rand.Float64()
+	}
+	return rand.Float64()
+}
+
+// maxEffectiveAttempts bounds the schedule simulated by EffectiveAttempts.
+const maxEffectiveAttempts = 1 << 20
+
+// EffectiveAttempts returns an upper bound of the attempts the strategy fits in the time budget of
+// RetryWithDeadline, assuming the attempts take no time and ignoring jitter, or math.MaxInt when its delays
+// stay at zero.
+func EffectiveAttempts(strategy BackoffStrategy, budget time.Duration) int {
+	c := newConfig([]Option{WithBackoff(strategy)})
+	var elapsed, delay time.Duration
+	for attempt := 1; attempt < maxEffectiveAttempts; attempt++ {
+		delay = c.nextDelay(attempt, delay)
+		elapsed += delay
+		if elapsed > budget {
+			return attempt
+		}
+	}
+	return unlimitedAttempts
+}
+
+// applyJitter returns a delay within [delay*(1-factor), delay*(1+factor)], given a random number r
+// within [0, 1).
+func applyJitter(delay time.Duration, factor, r float64) time.Duration {
+	spread := factor * float64(delay)
+	return time.Duration(float64(delay) - spread + r*2*spread)
+}
+
+// applyPositiveJitter returns a delay within [delay, delay*(1+factor)], given a random number r within [0, 1).
+func applyPositiveJitter(delay time.Duration, factor, r float64) time.Duration {
+	return time.Duration(float64(delay) + r*factor*float64(delay))
+}
@@ -0,0 +1,396 @@
+package retryable_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestConstantBackoff tests that the constant strategy always returns the same delay.
+func TestConstantBackoff(t *testing.T) {
+	backoff := retryable.ConstantBackoff(2 * time.Second)
+	for attempt := 1; attempt <= 3; attempt++ {
+		if delay := backoff.Delay(attempt); delay != 2*time.Second {
+			t.Errorf("Attempt %d: expected 2s, got %v", attempt, delay)
+		}
+	}
+}
+
+// TestExponentialBackoff tests the exponential schedule and its cap.
+func TestExponentialBackoff(t *testing.T) {
+	backoff := retryable.ExponentialBackoff(100*time.Millisecond, 2, 500*time.Millisecond)
+	expected := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 500 * time.Millisecond}
+	for i, want := range expected {
+		if got := backoff.Delay(i + 1); got != want {
+			t.Errorf("Attempt %d: expected %v, got %v", i+1, want, got)
+		}
+	}
+}
+
+// TestExponentialBackoffUncapped tests that a zero max never caps the delay and never overflows.
+func TestExponentialBackoffUncapped(t *testing.T) {
+	backoff := retryable.ExponentialBackoff(1*time.Second, 10, 0)
+	if got := backoff.Delay(4); got != 1000*time.Second {
+		t.Errorf("Expected 1000s, got %v", got)
+	}
+	if got := backoff.Delay(1000); got <= 0 {
+		t.Errorf("Expected a positive delay for a huge attempt number, got %v", got)
+	}
+}
+
+// recordDelays records the delays logged before each retry, and restores the logger when the test ends.
+func recordDelays(t *testing.T) *[]time.Duration {
+	t.Helper()
+	var delays []time.Duration
+	retryable.SetLoggerWriter(func(format string, args ...interface{}) {
+		delays = append(delays, args[len(args)-1].(time.Duration))
+	})
+	t.Cleanup(func() { retryable.SetLoggerWriter(log.Printf) })
+	return &delays
+}
+
+// failingFn returns a function that always fails.
+func failingFn() func() (int, error) {
+	return func() (int, error) {
+		return 0, errors.New("error")
+	}
+}
+
+// TestWithExponentialBackoff tests the schedule produced by the exponential option.
+func TestWithExponentialBackoff(t *testing.T) {
+	delays := recordDelays(t)
+
+	retryable.RetryWithOptions(failingFn(), retryable.WithMaxAttempts(4), retryable.WithExponentialBackoff(1*time.Millisecond, 2, 0))
+
+	expected := []time.Duration{1 * time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond}
+	if fmt.Sprint(*delays) != fmt.Sprint(expected) {
+		t.Errorf("Expected delays %v, got %v", expected, *delays)
+	}
+}
+
+// TestBackoffOptionsLastWins tests that the last backoff option replaces the previous ones.
+func TestBackoffOptionsLastWins(t *testing.T) {
+	delays := recordDelays(t)
+
+	retryable.RetryWithOptions(failingFn(),
+		retryable.WithMaxAttempts(3),
+		retryable.WithExponentialBackoff(1*time.Millisecond, 2, 0),
+		retryable.WithConstantBackoff(3*time.Millisecond),
+	)
+
+	expected := []time.Duration{3 * time.Millisecond, 3 * time.Millisecond}
+	if fmt.Sprint(*delays) != fmt.Sprint(expected) {
+		t.Errorf("Expected delays %v, got %v", expected, *delays)
+	}
+}
+
+// TestWithJitter tests that jitter keeps delays within bounds and composes regardless of option order.
+func TestWithJitter(t *testing.T) {
+	delays := recordDelays(t)
+
+	retryable.RetryWithOptions(failingFn(),
+		retryable.WithMaxAttempts(21),
+		retryable.WithJitter(0.5),
+		retryable.WithConstantBackoff(2*time.Millisecond),
+	)
+
+	distinct := map[time.Duration]bool{}
+	for _, delay := range *delays {
+		if delay < 1*time.Millisecond || delay > 3*time.Millisecond {
+			t.Errorf("Expected jittered delay within [1ms, 3ms], got %v", delay)
+		}
+		distinct[delay] = true
+	}
+	if len(distinct) < 2 {
+		t.Errorf("Expected jitter to randomize the delays, got %v", *delays)
+	}
+}
+
+// TestTieredBackoff tests the transition from the fast delay to the delegated strategy.
+func TestTieredBackoff(t *testing.T) {
+	backoff := retryable.TieredBackoff(10*time.Millisecond, 2, retryable.ExponentialBackoff(1*time.Second, 2, 0))
+	expected := []time.Duration{10 * time.Millisecond, 10 * time.Millisecond, 1 * time.Second, 2 * time.Second, 4 * time.Second}
+	for i, want := range expected {
+		if got := backoff.Delay(i + 1); got != want {
+			t.Errorf("Retry %d: expected %v, got %v", i+1, want, got)
+		}
+	}
+}
+
+// TestWithTieredBackoff tests the tiered schedule within a retry loop and its validation.
+func TestWithTieredBackoff(t *testing.T) {
+	delays := recordDelays(t)
+
+	retryable.RetryWithOptions(failingFn(),
+		retryable.WithMaxAttempts(4),
+		retryable.WithTieredBackoff(1*time.Millisecond, 1, retryable.ExponentialBackoff(2*time.Millisecond, 2, 0)),
+	)
+
+	expected := []time.Duration{1 * time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond}
+	if fmt.Sprint(*delays) != fmt.Sprint(expected) {
+		t.Errorf("Expected delays %v, got %v", expected, *delays)
+	}
+
+	err := retryable.ValidateConfig(retryable.WithTieredBackoff(-1, 1, retryable.ExponentialBackoff(1, 0.5, 0)))
+	if !errors.Is(err, retryable.ErrInvalidConfig) {
+		t.Errorf("Expected the tiered strategy and its delegate to be validated, got %v", err)
+	}
+}
+
+// TestWithMaxGrowthFactor tests that an aggressive delay function is smoothed to respect the growth factor.
+func TestWithMaxGrowthFactor(t *testing.T) {
+	delays := recordDelays(t)
+
+	spiky := retryable.BackoffFunc(func(attempt int) time.Duration {
+		if attempt == 1 {
+			return 1 * time.Millisecond
+		}
+		if attempt == 4 {
+			return 1 * time.Millisecond
+		}
+		return 60 * time.Millisecond
+	})
+	retryable.RetryWithOptions(failingFn(), retryable.WithMaxAttempts(6), retryable.WithBackoff(spiky), retryable.WithMaxGrowthFactor(2))
+
+	expected := []time.Duration{1 * time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond, 1 * time.Millisecond, 2 * time.Millisecond}
+	if fmt.Sprint(*delays) != fmt.Sprint(expected) {
+		t.Errorf("Expected delays %v, got %v", expected, *delays)
+	}
+	if err := retryable.ValidateConfig(retryable.WithMaxGrowthFactor(0.5)); !errors.Is(err, retryable.ErrInvalidConfig) {
+		t.Errorf("Expected a growth factor below 1 to be invalid, got %v", err)
+	}
+}
+
+// TestWithDelayRounding tests that jittered exponential delays are rounded to the unit.
+func TestWithDelayRounding(t *testing.T) {
+	delays := recordDelays(t)
+
+	retryable.RetryWithOptions(failingFn(),
+		retryable.WithMaxAttempts(4),
+		retryable.WithExponentialBackoff(1*time.Second, 2, 0),
+		retryable.WithJitter(0.1),
+		retryable.WithDelayRounding(1*time.Second),
+		retryable.WithClock(newFakeClock()),
+	)
+
+	expected := []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second}
+	if fmt.Sprint(*delays) != fmt.Sprint(expected) {
+		t.Errorf("Expected delays %v, got %v", expected, *delays)
+	}
+	if err := retryable.ValidateConfig(retryable.WithDelayRounding(0)); !errors.Is(err, retryable.ErrInvalidConfig) {
+		t.Errorf("Expected a zero rounding unit to be invalid, got %v", err)
+	}
+}
+
+// hintError is an error carrying a backoff hint.
+type hintError struct {
+	delay time.Duration
+	reset bool
+}
+
+func (e hintError) Error() string                      { return "hinted error" }
+func (e hintError) BackoffHint() (time.Duration, bool) { return e.delay, e.reset }
+
+// TestBackoffHinter tests that errors can override the next delay and reset the exponential progression.
+func TestBackoffHinter(t *testing.T) {
+	delays := recordDelays(t)
+
+	errs := []error{
+		errors.New("plain"),
+		errors.New("plain"),
+		fmt.Errorf("wrapped: %w", hintError{reset: true}),
+		errors.New("plain"),
+		hintError{delay: 7 * time.Second},
+		errors.New("plain"),
+	}
+	attempts := 0
+	retryable.RetryWithOptions(func() (int, error) {
+		attempts++
+		return 0, errs[attempts-1]
+	},
+		retryable.WithMaxAttempts(len(errs)),
+		retryable.WithExponentialBackoff(1*time.Second, 2, 0),
+		retryable.WithClock(newFakeClock()),
+	)
+
+	expected := []time.Duration{1 * time.Second, 2 * time.Second, 1 * time.Second, 2 * time.Second, 7 * time.Second}
+	if fmt.Sprint(*delays) != fmt.Sprint(expected) {
+		t.Errorf("Expected delays %v, got %v", expected, *delays)
+	}
+}
+
+// TestRampBackoff tests that the delay grows geometrically and reaches the cap exactly at the given attempt.
+func TestRampBackoff(t *testing.T) {
+	backoff := retryable.RampBackoff(100*time.Millisecond, 10*time.Second, 3)
+
+	expected := []time.Duration{100 * time.Millisecond, 1 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, want := range expected {
+		if got := backoff.Delay(i + 1); got != want {
+			t.Errorf("Expected delay %v after attempt %d, got %v", want, i+1, got)
+		}
+	}
+
+	curve := retryable.RampBackoff(1*time.Second, 1*time.Minute, 7)
+	if got := curve.Delay(7); got != 1*time.Minute {
+		t.Errorf("Expected the cap at attempt 7, got %v", got)
+	}
+	if got := curve.Delay(6); got >= 1*time.Minute || got <= curve.Delay(5) {
+		t.Errorf("Expected a growing delay below the cap at attempt 6, got %v", got)
+	}
+
+	for _, opt := range []retryable.Option{
+		retryable.WithBackoffRampTo(10*time.Second, 1*time.Second, 3),
+		retryable.WithBackoffRampTo(0, 1*time.Second, 3),
+		retryable.WithBackoffRampTo(1*time.Second, 10*time.Second, 1),
+	} {
+		if err := retryable.ValidateConfig(opt); !errors.Is(err, retryable.ErrInvalidConfig) {
+			t.Errorf("Expected an invalid ramp to be rejected, got %v", err)
+		}
+	}
+}
+
+// TestWithLoadAwareBackoff tests that the delays follow the pressure, read once per wait and clamped to the bounds.
+func TestWithLoadAwareBackoff(t *testing.T) {
+	delays := recordDelays(t)
+	readings := []float64{0, 0.5, 1, 3, -1}
+	reads := 0
+	pressure := func() float64 {
+		reads++
+		return readings[reads-1]
+	}
+
+	retryable.RetryWithOptions(failingFn(),
+		retryable.WithMaxAttempts(len(readings)+1),
+		retryable.WithLoadAwareBackoff(pressure, 1*time.Second, 5*time.Second),
+		retryable.WithClock(newFakeClock()),
+	)
+
+	expected := []time.Duration{1 * time.Second, 3 * time.Second, 5 * time.Second, 5 * time.Second, 1 * time.Second}
+	if fmt.Sprint(*delays) != fmt.Sprint(expected) || reads != len(readings) {
+		t.Errorf("Expected delays %v with one reading per wait, got %v after %d readings", expected, *delays, reads)
+	}
+}
+
+// TestWithMonotonicJitter tests that jittered delays never decrease, and never fall below the strategy.
+func TestWithMonotonicJitter(t *testing.T) {
+	delays := recordDelays(t)
+
+	retryable.RetryWithOptions(failingFn(),
+		retryable.WithMaxAttempts(50),
+		retryable.WithDelay(1*time.Second),
+		retryable.WithJitter(0.5),
+		retryable.WithMonotonicJitter(),
+		retryable.WithClock(newFakeClock()),
+	)
+
+	if len(*delays) != 49 {
+		t.Fatalf("Expected 49 delays, got %d", len(*delays))
+	}
+	for i, d := range *delays {
+		if d < 1*time.Second || d > 1500*time.Millisecond {
+			t.Errorf("Expected delay %d within [1s, 1.5s], got %v", i, d)
+		}
+		if i > 0 && d < (*delays)[i-1] {
+			t.Errorf("Expected non-decreasing delays, got %v after %v", d, (*delays)[i-1])
+		}
+	}
+}
+
+// TestWithFirstRetryImmediate tests that only the first retry is immediate and the later ones follow the backoff.
+func TestWithFirstRetryImmediate(t *testing.T) {
+	delays := recordDelays(t)
+
+	retryable.RetryWithOptions(failingFn(),
+		retryable.WithMaxAttempts(4),
+		retryable.WithExponentialBackoff(1*time.Millisecond, 2, 0),
+		retryable.WithMaxGrowthFactor(2),
+		retryable.WithFirstRetryImmediate(),
+	)
+
+	expected := []time.Duration{0, 2 * time.Millisecond, 4 * time.Millisecond}
+	if fmt.Sprint(*delays) != fmt.Sprint(expected) {
+		t.Errorf("Expected delays %v, got %v", expected, *delays)
+	}
+}
+
+// TestEffectiveAttempts tests the number of attempts fitting in a budget for several strategies.
+func TestEffectiveAttempts(t *testing.T) {
+	tests := []struct {
+		name     string
+		strategy retryable.BackoffStrategy
+		budget   time.Duration
+		expected int
+	}{
+		{"constant", retryable.ConstantBackoff(1 * time.Second), 3 * time.Second, 4},
+		{"constant below budget", retryable.ConstantBackoff(1 * time.Second), 2500 * time.Millisecond, 3},
+		{"exponential", retryable.ExponentialBackoff(1*time.Second, 2, 0), 10 * time.Second, 4},
+		{"capped exponential", retryable.ExponentialBackoff(1*time.Second, 2, 2*time.Second), 10 * time.Second, 6},
+		{"zero delay", retryable.ConstantBackoff(0), 1 * time.Second, math.MaxInt},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryable.EffectiveAttempts(tt.strategy, tt.budget); got != tt.expected {
+				t.Errorf("Expected %d attempts, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+// TestWithDelayModifier tests that the modifier scales the delays computed by the strategy.
+func TestWithDelayModifier(t *testing.T) {
+	delays := recordDelays(t)
+	pressure := 1.0
+
+	retryable.RetryWithOptions(failingFn(),
+		retryable.WithMaxAttempts(4),
+		retryable.WithExponentialBackoff(1*time.Millisecond, 2, 0),
+		retryable.WithDelayModifier(func(base time.Duration) time.Duration {
+			pressure++
+			return time.Duration(pressure * float64(base))
+		}),
+	)
+
+	expected := []time.Duration{2 * time.Millisecond, 6 * time.Millisecond, 16 * time.Millisecond}
+	if fmt.Sprint(*delays) != fmt.Sprint(expected) {
+		t.Errorf("Expected delays %v, got %v", expected, *delays)
+	}
+}
+
+// TestWithHistoryAwareDelay tests that the delay function sees every failed attempt so far, and its delays are waited.
+func TestWithHistoryAwareDelay(t *testing.T) {
+	clock := newFakeClock()
+	var latencies []time.Duration
+	attempts := 0
+
+	res := retryable.RetryWithResult(context.Background(), func(context.Context) (int, error) {
+		attempts++
+		clock.Advance(time.Duration(attempts) * time.Second)
+		return 0, fmt.Errorf("attempt %d", attempts)
+	},
+		retryable.WithMaxAttempts(4),
+		retryable.WithClock(clock),
+		retryable.WithHistoryAwareDelay(func(history []retryable.AttemptRecord) time.Duration {
+			last := history[len(history)-1]
+			if last.Attempt != len(history) || last.Err.Error() != fmt.Sprintf("attempt %d", len(history)) {
+				t.Errorf("Expected the last record to be attempt %d, got %+v", len(history), last)
+			}
+			latencies = append(latencies, last.Duration)
+			return time.Duration(len(history)) * time.Minute
+		}),
+	)
+
+	expected := []time.Duration{1 * time.Second, 2 * time.Second, 3 * time.Second}
+	if fmt.Sprint(latencies) != fmt.Sprint(expected) {
+		t.Errorf("Expected the latencies %v, got %v", expected, latencies)
+	}
+	if res.SleepTime != 6*time.Minute {
+		t.Errorf("Expected to sleep 6m, got %v", res.SleepTime)
+	}
+}
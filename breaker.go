@@ -0,0 +1,123 @@
+package retryable
+
+import (
+	"sync"
+	"time"
+)
+
+// CircuitBreakerConfig configures a CircuitBreaker.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failed attempts that opens the circuit. It must be
+	// at least 1.
+	FailureThreshold int
+	// Cooldown is how long the circuit stays open before a probe attempt is let through. It must be
+	// positive.
+	Cooldown time.Duration
+	// MaxCooldown caps the open period, which doubles each time a probe fails. Zero, or any value not
+	// greater than Cooldown, keeps the circuit open for Cooldown after every failed probe.
+	MaxCooldown time.Duration
+}
+
+// validate reports the invalid fields of the configuration.
+func (bc CircuitBreakerConfig) validate() error {
+	if bc.FailureThreshold < 1 {
+		return invalidConfig("circuit breaker failure threshold must be at least 1, got %d", bc.FailureThreshold)
+	}
+	if bc.Cooldown <= 0 {
+		return invalidConfig("circuit breaker cooldown must be positive, got %v", bc.Cooldown)
+	}
+	return nil
+}
+
+// CircuitBreaker suppresses the attempts of every loop configured with it, see WithCircuitBreaker, once
+// FailureThreshold consecutive attempts have failed, letting a single probe through after each cooldown. It
+// is safe for concurrent use.
+type CircuitBreaker struct {
+	cfg CircuitBreakerConfig
+
+	mu       sync.Mutex
+	failures int
+	open     bool
+	openedAt time.Time
+	openFor  time.Duration
+	probing  bool
+	probedAt time.Time
+}
+
+// NewCircuitBreaker returns a closed CircuitBreaker. The configuration is validated by WithCircuitBreaker.
+func NewCircuitBreaker(cfg CircuitBreakerConfig) *CircuitBreaker {
+	return &CircuitBreaker{cfg: cfg, openFor: cfg.Cooldown}
+}
+
+// WithCircuitBreaker makes the loop consult the circuit breaker before each attempt: when the circuit is
+// open the loop stops, without waiting, with OutcomeCircuitOpen and a *RetryError matching ErrCircuitOpen
+// that wraps the last error, or ErrCircuitOpen itself if no attempt was made. Every attempt made reports
+// its outcome to the breaker, whatever the classification of its error.
+func WithCircuitBreaker(cb *CircuitBreaker) Option {
+	return func(c *config) {
+		if cb == nil {
+			c.invalid("circuit breaker must not be nil")
+			return
+		}
+		if err := cb.cfg.validate(); err != nil {
+			c.problems = append(c.problems, err)
+		}
+		c.breaker = cb
+	}
+}
+
+// allow reports whether an attempt may be made at now, and whether it is the probe of an open circuit.
+func (b *CircuitBreaker) allow(now time.Time) (ok, probe bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.open {
+		return true, false
+	}
+	if now.Sub(b.openedAt) < b.openFor {
+		return false, false
+	}
+	if b.probing && now.Sub(b.probedAt) < b.openFor {
+		// Another caller is probing; a probe that never reported is given up after the open period.
+		return false, false
+	}
+	b.probing = true
+	b.probedAt = now
+	return true, true
+}
+
+// abandonProbe frees the slot of an admitted probe that did not report, e.g. because the context
+// was done while waiting for a concurrency slot, so that another caller may probe without waiting.
+func (b *CircuitBreaker) abandonProbe() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// record reports the outcome of an attempt allowed at now.
+func (b *CircuitBreaker) record(now time.Time, probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		b.failures = 0
+		b.open = false
+		b.probing = false
+		b.openFor = b.cfg.Cooldown
+		return
+	}
+	if probe {
+		b.probing = false
+		b.openedAt = now
+		b.openFor = min(2*b.openFor, max(b.cfg.MaxCooldown, b.cfg.Cooldown))
+		return
+	}
+	if b.open {
+		// An attempt started before the circuit opened does not extend the open period.
+		return
+	}
+	b.failures++
+	if b.failures >= b.cfg.FailureThreshold {
+		b.open = true
+		b.openedAt = now
+		b.openFor = b.cfg.Cooldown
+	}
+}
@@ -0,0 +1,109 @@
+package retryable_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestWithCircuitBreaker tests that the loop stops as soon as the consecutive failures open the circuit.
+func TestWithCircuitBreaker(t *testing.T) {
+	cb := retryable.NewCircuitBreaker(retryable.CircuitBreakerConfig{FailureThreshold: 2, Cooldown: 10 * time.Second})
+	attempts := 0
+	res := retryable.RetryWithResult(context.Background(), func(context.Context) (int, error) {
+		attempts++
+		return 0, errors.New("down")
+	}, retryable.WithMaxAttempts(5), retryable.WithDelay(1*time.Second), retryable.WithClock(newFakeClock()), retryable.WithCircuitBreaker(cb))
+
+	if attempts != 2 || res.Outcome != retryable.OutcomeCircuitOpen || !errors.Is(res.Err, retryable.ErrCircuitOpen) || res.Err.Error() != "circuit open after 2 attempt(s): down" {
+		t.Errorf("Expected the circuit to open after 2 attempts, got %v after %d attempts", res.Err, attempts)
+	}
+}
+
+// TestCircuitBreakerHalfOpen tests that an open circuit lets a single probe through after the cooldown,
+// extending the open period when it fails and closing the circuit when it succeeds.
+func TestCircuitBreakerHalfOpen(t *testing.T) {
+	clock := newFakeClock()
+	cb := retryable.NewCircuitBreaker(retryable.CircuitBreakerConfig{FailureThreshold: 1, Cooldown: 10 * time.Second, MaxCooldown: time.Minute})
+	call := func(fn func() error) (bool, error) {
+		called := false
+		res := retryable.RetryWithResult(context.Background(), func(context.Context) (int, error) {
+			called = true
+			return 0, fn()
+		}, retryable.WithMaxAttempts(1), retryable.WithClock(clock), retryable.WithCircuitBreaker(cb))
+		return called, res.Err
+	}
+	fail := func() error { return errors.New("down") }
+	succeed := func() error { return nil }
+
+	call(fail)
+	if called, err := call(succeed); called || !errors.Is(err, retryable.ErrCircuitOpen) {
+		t.Fatalf("Expected the open circuit to suppress the attempt, got %v", err)
+	}
+
+	clock.Advance(10 * time.Second)
+	probing := make(chan struct{})
+	release := make(chan struct{})
+	done := make(chan bool)
+	go func() {
+		called, _ := call(func() error {
+			close(probing)
+			<-release
+			return errors.New("still down")
+		})
+		done <- called
+	}()
+	<-probing
+	if called, _ := call(succeed); called {
+		t.Error("Expected a single probe while the circuit is half-open")
+	}
+	close(release)
+	if !<-done {
+		t.Fatal("Expected the probe to be let through after the cooldown")
+	}
+
+	clock.Advance(10 * time.Second)
+	if called, _ := call(succeed); called {
+		t.Error("Expected the failed probe to extend the open period")
+	}
+	clock.Advance(10 * time.Second)
+	if called, err := call(succeed); !called || err != nil {
+		t.Fatalf("Expected the probe to succeed after the extended period, got %v", err)
+	}
+	if called, err := call(succeed); !called || err != nil {
+		t.Errorf("Expected the circuit to be closed after a successful probe, got %v", err)
+	}
+}
+
+// TestCircuitBreakerAbandonedProbe tests that a probe canceled while waiting for a concurrency slot frees
+// the probe slot for the next caller.
+func TestCircuitBreakerAbandonedProbe(t *testing.T) {
+	clock := newFakeClock()
+	cb := retryable.NewCircuitBreaker(retryable.CircuitBreakerConfig{FailureThreshold: 1, Cooldown: 10 * time.Second})
+	calls := 0
+	call := func(ctx context.Context, opts ...retryable.Option) error {
+		opts = append(opts, retryable.WithMaxAttempts(1), retryable.WithClock(clock), retryable.WithCircuitBreaker(cb))
+		return retryable.RetryWithResult(ctx, func(context.Context) (int, error) {
+			calls++
+			return 0, nil
+		}, opts...).Err
+	}
+	retryable.RetryWithResult(context.Background(), func(context.Context) (int, error) {
+		return 0, errors.New("down")
+	}, retryable.WithMaxAttempts(1), retryable.WithClock(clock), retryable.WithCircuitBreaker(cb))
+	clock.Advance(10 * time.Second)
+
+	sem := make(chan struct{}, 1)
+	sem <- struct{}{}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	if err := call(ctx, retryable.WithConcurrencyLimit(sem)); !errors.Is(err, context.Canceled) || calls != 0 {
+		t.Fatalf("Expected the probe to be canceled while waiting for a slot, got %v after %d calls", err, calls)
+	}
+	if err := call(context.Background()); err != nil || calls != 1 {
+		t.Errorf("Expected the next caller to probe, got %v after %d calls", err, calls)
+	}
+}
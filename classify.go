@@ -0,0 +1,100 @@
+package retryable
+
+import (
+	"errors"
+	"io"
+)
+
+// Permanent marks err as non-retryable, so the retry functions that classify errors stop at once at an
+// error with it in its chain. The returned error matches ErrNonRetryable and unwraps to err; it is nil if
+// err is nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return nonRetryable(err)
+}
+
+// Retryable marks err as retryable, overriding the classification and the default for io.EOF, but not
+// Permanent. The returned error unwraps to err; it is nil if err is nil.
+func Retryable(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &retryableError{err: err}
+}
+
+// halts reports whether err stops the retries: always when it matches ErrNonRetryable, as when marked
+// with Permanent, never when marked with Retryable, and otherwise when it matches io.EOF or the
+// classification reports it as non-retryable.
+func halts(err error, nonRetryable func(error) bool) bool {
+	halt, _ := classify(err, "", func(err error) bool { return errors.Is(err, io.EOF) || nonRetryable(err) })
+	return halt
+}
+
+// isEOF reports whether err matches io.EOF without being marked with Retryable, which stops the positional
+// functions retrying any other error.
+func isEOF(err error) bool {
+	var forced *retryableError
+	return errors.Is(err, io.EOF) && !errors.As(err, &forced)
+}
+
+// classify returns the decision of halts along with the classification reported in a RetryEvent, rule
+// naming the classification applied by nonRetryable.
+func classify(err error, rule string, nonRetryable func(error) bool) (halt bool, classification string) {
+	if errors.Is(err, ErrNonRetryable) {
+		return true, "non-retryable:permanent"
+	}
+	var forced *retryableError
+	if errors.As(err, &forced) {
+		return false, "retryable:marked"
+	}
+	if nonRetryable(err) {
+		return true, "non-retryable:" + rule
+	}
+	return false, "retryable:" + rule
+}
+
+// nonRetryableError wraps an error that halted the retries, keeping its message.
+type nonRetryableError struct {
+	err error
+}
+
+// nonRetryable wraps an error that halted the retries so that it matches ErrNonRetryable, unless it
+// already does.
+func nonRetryable(err error) error {
+	if errors.Is(err, ErrNonRetryable) {
+		return err
+	}
+	return &nonRetryableError{err: err}
+}
+
+// Error implements the error interface, returning the message of the wrapped error unchanged.
+func (e *nonRetryableError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *nonRetryableError) Unwrap() error {
+	return e.err
+}
+
+// Is reports whether target is ErrNonRetryable.
+func (e *nonRetryableError) Is(target error) bool {
+	return target == ErrNonRetryable
+}
+
+// retryableError wraps an error marked with Retryable, keeping its message.
+type retryableError struct {
+	err error
+}
+
+// Error implements the error interface, returning the message of the wrapped error unchanged.
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
@@ -0,0 +1,67 @@
+package retryable_test
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestRetryable tests that a marked error is retried although the classification would stop at it.
+func TestRetryable(t *testing.T) {
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, fmt.Errorf("query: %w", retryable.Retryable(errors.New("fatal: lock timeout")))
+		}
+		return attempts, nil
+	}
+
+	if _, err := retryable.RetryWithNonRetryableErrors(fn, 5, 1*time.Millisecond, []string{"fatal"}); err != nil || attempts != 3 {
+		t.Errorf("Expected the list-based function to retry the marked error, got %v after %d attempts", err, attempts)
+	}
+
+	attempts = 0
+	_, err := retryable.RetryWithOptions(fn, retryable.WithDelay(1*time.Millisecond), retryable.WithRetryIf(func(error) bool { return false }))
+	if err != nil || attempts != 3 {
+		t.Errorf("Expected WithRetryIf to be overridden, got %v after %d attempts", err, attempts)
+	}
+	if retryable.Retryable(io.EOF).Error() != io.EOF.Error() || !errors.Is(retryable.Retryable(io.EOF), io.EOF) {
+		t.Error("Expected the marked error to keep its message and unwrap to the original")
+	}
+}
+
+// TestPermanent tests that a marked error stops the retries, even when also marked as retryable.
+func TestPermanent(t *testing.T) {
+	for _, mark := range []func(error) error{
+		retryable.Permanent,
+		func(err error) error { return retryable.Retryable(retryable.Permanent(err)) },
+		func(err error) error { return retryable.Permanent(retryable.Retryable(err)) },
+	} {
+		attempts := 0
+		fn := func() (int, error) {
+			attempts++
+			return 0, mark(errors.New("invalid token"))
+		}
+
+		_, err := retryable.RetryWithCustomCheck(fn, 5, 1*time.Millisecond, func(error) bool { return true })
+		if attempts != 1 || !errors.Is(err, retryable.ErrNonRetryable) || err.Error() != "invalid token" {
+			t.Errorf("Expected the custom check to be overridden, got %v after %d attempts", err, attempts)
+		}
+
+		attempts = 0
+		_, err = retryable.RetryWithOptions(fn, retryable.WithDelay(1*time.Millisecond))
+		var retryErr *retryable.RetryError
+		if attempts != 1 || !errors.As(err, &retryErr) || retryErr.Outcome != retryable.OutcomeNonRetryable {
+			t.Errorf("Expected a single non-retryable attempt, got %v after %d attempts", err, attempts)
+		}
+	}
+
+	if retryable.Permanent(nil) != nil || retryable.Retryable(nil) != nil {
+		t.Error("Expected nil errors to stay nil")
+	}
+}
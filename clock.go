@@ -0,0 +1,36 @@
+package retryable
+
+import (
+	"context"
+	"time"
+)
+
+// Clock abstracts the passage of time for the option-based functions, so tests can use a fake clock
+// to assert time-dependent behaviour without real waiting.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+	// Sleep waits for the duration and returns nil, or returns the context error as soon as the
+	// context is done.
+	Sleep(ctx context.Context, d time.Duration) error
+}
+
+// realClock is the Clock backed by the time package.
+type realClock struct{}
+
+// Now implements Clock.
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// Sleep implements Clock.
+func (realClock) Sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
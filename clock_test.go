@@ -0,0 +1,102 @@
+package retryable_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// fakeClock is a Clock whose time only moves when Sleep or Advance is called.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.Advance(d)
+	return nil
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+// TestRetryWithDeadlineFakeClock tests that RetryWithDeadline stops exactly at the budget.
+func TestRetryWithDeadlineFakeClock(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		return 0, errors.New("not ready")
+	}
+
+	_, err := retryable.RetryWithDeadline(fn, 3*time.Second, retryable.WithDelay(1*time.Second), retryable.WithClock(clock))
+
+	var retryErr *retryable.RetryError
+	if !errors.As(err, &retryErr) || retryErr.Outcome != retryable.OutcomeDeadlineExceeded {
+		t.Fatalf("Expected deadline exceeded outcome, got %v", err)
+	}
+	if elapsed := clock.Now().Sub(start); elapsed != 3*time.Second {
+		t.Errorf("Expected to stop exactly at the 3s budget, stopped after %v", elapsed)
+	}
+	if attempts != 4 {
+		t.Errorf("Expected attempts at 0s, 1s, 2s and 3s, got %d attempts", attempts)
+	}
+}
+
+// TestRetryWithDeadlineSlowAttempts tests that the attempt durations count against the budget.
+func TestRetryWithDeadlineSlowAttempts(t *testing.T) {
+	clock := newFakeClock()
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		clock.Advance(2 * time.Second)
+		if attempts < 10 {
+			return 0, errors.New("slow failure")
+		}
+		return attempts, nil
+	}
+
+	_, err := retryable.RetryWithDeadline(fn, 5*time.Second, retryable.WithDelay(1*time.Second), retryable.WithClock(clock))
+	if err == nil || attempts != 2 {
+		t.Errorf("Expected to give up after 2 slow attempts, got %d attempts with error %v", attempts, err)
+	}
+}
+
+// TestWithAfterAttemptFakeClockDuration tests that attempt durations are measured with the clock.
+func TestWithAfterAttemptFakeClockDuration(t *testing.T) {
+	clock := newFakeClock()
+	var duration time.Duration
+	fn := func() (bool, error) {
+		clock.Advance(250 * time.Millisecond)
+		return true, nil
+	}
+
+	_, err := retryable.RetryWithOptions(fn,
+		retryable.WithClock(clock),
+		retryable.WithAfterAttempt(func(_ int, _ error, d time.Duration) { duration = d }),
+	)
+	if err != nil || duration != 250*time.Millisecond {
+		t.Errorf("Expected a 250ms attempt, got %v with error %v", duration, err)
+	}
+}
@@ -0,0 +1,38 @@
+package retryable
+
+import "context"
+
+// WithConcurrencyLimit caps how many attempts run at once across the loops sharing sem to its capacity,
+// which must be at least 1. A slot is held during the call only, and waiting for it stops with the context
+// or the shutdown of the Retrier.
+func WithConcurrencyLimit(sem chan struct{}) Option {
+	return func(c *config) {
+		if cap(sem) < 1 {
+			c.invalid("concurrency limit must be at least 1, got %d", cap(sem))
+		}
+		c.sem = sem
+	}
+}
+
+// acquire waits for a slot of the concurrency limit, if any, returning the context error or ErrShutdown
+// if the loop must stop first.
+func (c *config) acquire(ctx context.Context) error {
+	if c.sem == nil {
+		return nil
+	}
+	select {
+	case c.sem <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-c.shutdown:
+		return ErrShutdown
+	}
+}
+
+// release frees the slot taken by acquire.
+func (c *config) release() {
+	if c.sem != nil {
+		<-c.sem
+	}
+}
@@ -0,0 +1,116 @@
+package retryable_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestWithConcurrencyLimit tests that the calls of a Retrier never run more attempts at once than the limit.
+func TestWithConcurrencyLimit(t *testing.T) {
+	sem := make(chan struct{}, 2)
+	r := retryable.NewRetrier(retryable.WithMaxAttempts(3), retryable.WithDelay(1*time.Millisecond), retryable.WithConcurrencyLimit(sem))
+
+	var running, peak atomic.Int32
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			attempts := 0
+			_ = r.Do(context.Background(), func(context.Context) error {
+				n := running.Add(1)
+				defer running.Add(-1)
+				for {
+					p := peak.Load()
+					if n <= p || peak.CompareAndSwap(p, n) {
+						break
+					}
+				}
+				time.Sleep(2 * time.Millisecond)
+				attempts++
+				if attempts < 2 {
+					return errors.New("overloaded")
+				}
+				return nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	if peak.Load() > 2 {
+		t.Errorf("Expected at most 2 concurrent attempts, got %d", peak.Load())
+	}
+	if len(sem) != 0 {
+		t.Errorf("Expected every slot to be released, got %d still held", len(sem))
+	}
+}
+
+// TestWithConcurrencyLimitCanceledWhileWaiting tests that waiting for a slot stops when the context is done.
+func TestWithConcurrencyLimitCanceledWhileWaiting(t *testing.T) {
+	sem := make(chan struct{}, 1)
+	sem <- struct{}{}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	called := false
+	_, err := retryable.RetryWithContext(ctx, func(context.Context) (int, error) {
+		called = true
+		return 0, nil
+	}, retryable.WithConcurrencyLimit(sem))
+
+	if called {
+		t.Error("Expected the function not to be called without a slot")
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the context error, got %v", err)
+	}
+	if len(sem) != 1 {
+		t.Errorf("Expected the held slot to be untouched, got %d", len(sem))
+	}
+}
+
+// TestWithConcurrencyLimitReleasedOnPanic tests that a panicking attempt releases its slot.
+func TestWithConcurrencyLimitReleasedOnPanic(t *testing.T) {
+	sem := make(chan struct{}, 1)
+	func() {
+		defer func() { _ = recover() }()
+		_, _ = retryable.RetryWithOptions(func() (int, error) {
+			panic("boom")
+		}, retryable.WithConcurrencyLimit(sem))
+	}()
+
+	if len(sem) != 0 {
+		t.Errorf("Expected the slot to be released after a panic, got %d still held", len(sem))
+	}
+}
+
+// TestWithConcurrencyLimitBeforeAttempt tests that the hook of WithBeforeAttempt runs once the slot is taken,
+// so it does not include the time spent waiting for it.
+func TestWithConcurrencyLimitBeforeAttempt(t *testing.T) {
+	sem := make(chan struct{}, 1)
+	sem <- struct{}{}
+	var hooked atomic.Bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, _ = retryable.RetryWithContext(context.Background(), func(context.Context) (int, error) {
+			return 1, nil
+		}, retryable.WithConcurrencyLimit(sem), retryable.WithBeforeAttempt(func(int) { hooked.Store(true) }))
+	}()
+
+	time.Sleep(10 * time.Millisecond)
+	if hooked.Load() {
+		t.Error("Expected the hook to wait for the slot")
+	}
+	<-sem
+	<-done
+	if !hooked.Load() {
+		t.Error("Expected the hook to run once the slot was taken")
+	}
+}
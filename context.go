@@ -0,0 +1,68 @@
+package retryable
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// retriesDisabledKey is the context key set by WithRetriesDisabled.
+type retriesDisabledKey struct{}
+
+// WithRetriesDisabled returns a copy of ctx making the context-aware functions call the function exactly
+// once, so a caller already retrying at a higher layer can avoid nested retries.
+func WithRetriesDisabled(ctx context.Context) context.Context {
+	return context.WithValue(ctx, retriesDisabledKey{}, true)
+}
+
+// RetriesDisabled reports whether retries were disabled in ctx with WithRetriesDisabled.
+func RetriesDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(retriesDisabledKey{}).(bool)
+	return disabled
+}
+
+// remainingAttemptsKey is the context key under which the loop stores the number of attempts left after
+// the current one.
+type remainingAttemptsKey struct{}
+
+// RemainingAttempts returns the number of attempts left after the current one, zero on the final attempt,
+// from the context passed to the function by a context-aware retry loop. It returns -1 for unlimited
+// attempts or outside a retry loop.
+func RemainingAttempts(ctx context.Context) int {
+	remaining, ok := ctx.Value(remainingAttemptsKey{}).(int)
+	if !ok {
+		return -1
+	}
+	return remaining
+}
+
+// defaultContext holds the context set with SetDefaultContext, nil until then.
+var defaultContext atomic.Pointer[context.Context]
+
+// SetDefaultContext sets a context, e.g. canceled on shutdown, that cuts short the waits of the functions
+// taking no context, except RetryWithSleeper. A nil context restores context.Background.
+func SetDefaultContext(ctx context.Context) {
+	if ctx == nil {
+		defaultContext.Store(nil)
+		return
+	}
+	defaultContext.Store(&ctx)
+}
+
+// DefaultContext returns the context set with SetDefaultContext, or context.Background if none was set.
+func DefaultContext() context.Context {
+	if ctx := defaultContext.Load(); ctx != nil {
+		return *ctx
+	}
+	return context.Background()
+}
+
+// sleepDefault waits for the delay or until the default context is done, returning its error then.
+func sleepDefault(delay time.Duration) error {
+	ctx := DefaultContext()
+	if ctx.Done() == nil {
+		time.Sleep(delay)
+		return nil
+	}
+	return realClock{}.Sleep(ctx, delay)
+}
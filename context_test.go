@@ -0,0 +1,94 @@
+package retryable_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestWithRetriesDisabled tests that a context flag limits the context-aware functions to a single attempt.
+func TestWithRetriesDisabled(t *testing.T) {
+	ctx := retryable.WithRetriesDisabled(context.Background())
+	if !retryable.RetriesDisabled(ctx) || retryable.RetriesDisabled(context.Background()) {
+		t.Fatalf("Expected the flag to be set only on the derived context")
+	}
+
+	attempts := 0
+	fn := func(context.Context) (int, error) {
+		attempts++
+		return 0, errors.New("error")
+	}
+
+	_, err := retryable.RetryWithContext(ctx, fn, retryable.WithMaxAttempts(5), retryable.WithDelay(1*time.Millisecond))
+	if err == nil || attempts != 1 {
+		t.Errorf("Expected a single attempt, got %d with error %v", attempts, err)
+	}
+
+	attempts = 0
+	r := retryable.NewRetrier(retryable.WithMaxAttempts(5), retryable.WithDelay(1*time.Millisecond))
+	r.Do(ctx, func(ctx context.Context) error {
+		_, err := fn(ctx)
+		return err
+	})
+	if attempts != 1 {
+		t.Errorf("Expected a single attempt through a Retrier, got %d", attempts)
+	}
+}
+
+// TestRemainingAttempts tests that the function can tell its final attempt from the context.
+func TestRemainingAttempts(t *testing.T) {
+	var remaining []int
+	retryable.RetryWithContext(context.Background(), func(ctx context.Context) (int, error) {
+		remaining = append(remaining, retryable.RemainingAttempts(ctx))
+		if retryable.RemainingAttempts(ctx) == 0 {
+			return 1, nil
+		}
+		return 0, errors.New("fast path failed")
+	}, retryable.WithMaxAttempts(3), retryable.WithDelay(1*time.Millisecond))
+
+	if fmt.Sprint(remaining) != "[2 1 0]" {
+		t.Errorf("Expected remaining attempts [2 1 0], got %v", remaining)
+	}
+	if got := retryable.RemainingAttempts(context.Background()); got != -1 {
+		t.Errorf("Expected -1 outside a retry loop, got %d", got)
+	}
+}
+
+// TestSetDefaultContext tests that the functions without context stop waiting once the default context is done.
+func TestSetDefaultContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	retryable.SetDefaultContext(ctx)
+	defer retryable.SetDefaultContext(nil)
+	oldDelay := retryable.DefaultDelay
+	retryable.DefaultDelay = 1 * time.Hour
+	defer func() { retryable.DefaultDelay = oldDelay }()
+
+	errDown := errors.New("down")
+	fn := func() (int, error) {
+		time.AfterFunc(10*time.Millisecond, cancel)
+		return 0, errDown
+	}
+
+	start := time.Now()
+	_, err := retryable.MustRetry(fn)
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, errDown) {
+		t.Errorf("Expected the cancellation joined with the last error, got %v", err)
+	}
+	_, err = retryable.RetryWithOptions(fn)
+	var retryErr *retryable.RetryError
+	if !errors.As(err, &retryErr) || retryErr.Outcome != retryable.OutcomeCanceled {
+		t.Errorf("Expected a canceled *RetryError, got %v", err)
+	}
+	if time.Since(start) > 1*time.Second {
+		t.Errorf("Expected the waits to end early, took %v", time.Since(start))
+	}
+
+	_, err = retryable.RetryWithContext(context.Background(), func(context.Context) (int, error) { return 1, nil })
+	if err != nil {
+		t.Errorf("Expected a per-call context to override the default one, got %v", err)
+	}
+}
@@ -0,0 +1,95 @@
+// Package grpcretry retries gRPC unary calls with the retry policies of the retryable package, through a
+// client interceptor. It is a separate module, with its own go.mod, so that the users of retryable who
+// do not use gRPC do not depend on it.
+package grpcretry
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// PushbackKey is the trailer metadata key under which a server tells the client how long to wait before
+// retrying, in milliseconds, to retry at once with 0, or not to retry at all with a negative or malformed
+// value.
+const PushbackKey = "grpc-retry-pushback-ms"
+
+// DefaultCodes lists the codes retried by UnaryClientInterceptor unless another check is given with
+// WithCodes or retryable.WithRetryIf: Unavailable, the server being down or unreachable for now, and
+// ResourceExhausted, the server or the client being out of quota, which both pass with time.
+// Changing it changes the codes retried by the interceptors built afterwards.
+var DefaultCodes = []codes.Code{codes.Unavailable, codes.ResourceExhausted}
+
+// WithCodes makes the interceptor retry the errors with one of the given codes, and only them, instead of
+// DefaultCodes. It is retryable.WithRetryIf with a check of the status code.
+func WithCodes(retried ...codes.Code) retryable.Option {
+	return retryable.WithRetryIf(func(err error) bool {
+		return slices.Contains(retried, status.Code(err))
+	})
+}
+
+// UnaryClientInterceptor returns a grpc.UnaryClientInterceptor retrying the unary calls failing with a code
+// in DefaultCodes with the options, waiting the delay of PushbackKey when the server sets one. When it
+// gives up, the last error of the invoker is returned as is, or the status of the context error once it is
+// done.
+func UnaryClientInterceptor(opts ...retryable.Option) grpc.UnaryClientInterceptor {
+	opts = append([]retryable.Option{WithCodes(DefaultCodes...)}, opts...)
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, callOpts ...grpc.CallOption) error {
+		var last error
+		_, err := retryable.RetryWithContext(ctx, func(ctx context.Context) (struct{}, error) {
+			var trailer metadata.MD
+			last = invoker(ctx, method, req, reply, cc, append(callOpts, grpc.Trailer(&trailer))...)
+			return struct{}{}, pushback(last, trailer)
+		}, opts...)
+		switch {
+		case err == nil:
+			return nil
+		case ctx.Err() != nil:
+			return status.FromContextError(ctx.Err()).Err()
+		case last != nil:
+			return last
+		default:
+			return err
+		}
+	}
+}
+
+// pushbackError is the error of an attempt whose trailer held a valid pushback.
+type pushbackError struct {
+	err   error
+	delay time.Duration
+}
+
+// Error returns the message of the error of the attempt.
+func (e *pushbackError) Error() string { return e.err.Error() }
+
+// Unwrap returns the error of the attempt.
+func (e *pushbackError) Unwrap() error { return e.err }
+
+// BackoffHint implements retryable.BackoffHinter with the pushback delay.
+func (e *pushbackError) BackoffHint() (time.Duration, bool) { return e.delay, false }
+
+// pushback applies the pushback of the trailer, if any, to the error of an attempt.
+func pushback(err error, trailer metadata.MD) error {
+	values := trailer.Get(PushbackKey)
+	if err == nil || len(values) == 0 {
+		return err
+	}
+	ms, parseErr := strconv.ParseInt(values[0], 10, 64)
+	if parseErr != nil || ms < 0 {
+		return retryable.Permanent(err)
+	}
+	if ms == 0 {
+		// A zero hint leaves the delay to the strategy, so retrying at once takes the smallest positive one.
+		return &pushbackError{err: err, delay: time.Nanosecond}
+	}
+	return &pushbackError{err: err, delay: time.Duration(ms) * time.Millisecond}
+}
@@ -0,0 +1,143 @@
+package grpcretry_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/raniellyferreira/go-retryable"
+	"github.com/raniellyferreira/go-retryable/grpcretry"
+)
+
+// invoker returns a grpc.UnaryInvoker failing with the given errors in turn, then succeeding, setting the
+// trailer of each attempt, if any, and counting the attempts.
+func invoker(attempts *int, errs []error, trailers ...metadata.MD) grpc.UnaryInvoker {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+		*attempts++
+		if *attempts <= len(trailers) {
+			for _, opt := range opts {
+				if trailer, ok := opt.(grpc.TrailerCallOption); ok {
+					*trailer.TrailerAddr = trailers[*attempts-1]
+				}
+			}
+		}
+		if *attempts <= len(errs) {
+			return errs[*attempts-1]
+		}
+		return nil
+	}
+}
+
+// call invokes the interceptor built with the options around the invoker.
+func call(ctx context.Context, inv grpc.UnaryInvoker, opts ...retryable.Option) error {
+	return grpcretry.UnaryClientInterceptor(opts...)(ctx, "/test.Service/Method", nil, nil, nil, inv)
+}
+
+// TestUnaryClientInterceptor tests that the default codes are retried until the call succeeds.
+func TestUnaryClientInterceptor(t *testing.T) {
+	attempts := 0
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	exhausted := status.Error(codes.ResourceExhausted, "exhausted")
+
+	err := call(context.Background(), invoker(&attempts, []error{unavailable, exhausted}), retryable.WithDelay(time.Millisecond))
+	if err != nil || attempts != 3 {
+		t.Errorf("Expected success on the third attempt, got %v after %d attempts", err, attempts)
+	}
+}
+
+// TestUnaryClientInterceptorNonRetryable tests that other codes are returned as is, without retrying.
+func TestUnaryClientInterceptorNonRetryable(t *testing.T) {
+	attempts := 0
+	invalid := status.Error(codes.InvalidArgument, "invalid")
+
+	err := call(context.Background(), invoker(&attempts, []error{invalid}), retryable.WithDelay(time.Millisecond))
+	if err != invalid || attempts != 1 {
+		t.Errorf("Expected the error of the single attempt, got %v after %d attempts", err, attempts)
+	}
+}
+
+// TestUnaryClientInterceptorWithCodes tests that WithCodes replaces the default codes.
+func TestUnaryClientInterceptorWithCodes(t *testing.T) {
+	attempts := 0
+	aborted := status.Error(codes.Aborted, "aborted")
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	err := call(context.Background(), invoker(&attempts, []error{aborted, unavailable}),
+		retryable.WithDelay(time.Millisecond), grpcretry.WithCodes(codes.Aborted))
+	if status.Code(err) != codes.Unavailable || attempts != 2 {
+		t.Errorf("Expected to stop at the unavailable error, got %v after %d attempts", err, attempts)
+	}
+}
+
+// TestUnaryClientInterceptorGiveUp tests that the error of the last attempt is returned once the attempts run out.
+func TestUnaryClientInterceptorGiveUp(t *testing.T) {
+	attempts := 0
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	err := call(context.Background(), invoker(&attempts, []error{unavailable, unavailable, unavailable}),
+		retryable.WithMaxAttempts(2), retryable.WithDelay(time.Millisecond))
+	if err != unavailable || attempts != 2 {
+		t.Errorf("Expected the error of the last attempt, got %v after %d attempts", err, attempts)
+	}
+}
+
+// TestUnaryClientInterceptorPushback tests that a positive pushback replaces the delay of the strategy.
+func TestUnaryClientInterceptorPushback(t *testing.T) {
+	attempts := 0
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	start := time.Now()
+
+	err := call(context.Background(), invoker(&attempts, []error{unavailable}, metadata.Pairs(grpcretry.PushbackKey, "5")),
+		retryable.WithDelay(time.Hour))
+	if err != nil || attempts != 2 {
+		t.Errorf("Expected success on the second attempt, got %v after %d attempts", err, attempts)
+	}
+	if elapsed := time.Since(start); elapsed < 5*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Expected to wait the pushback of 5ms, took %v", elapsed)
+	}
+}
+
+// TestUnaryClientInterceptorZeroPushback tests that a zero pushback retries at once, without the delay of the strategy.
+func TestUnaryClientInterceptorZeroPushback(t *testing.T) {
+	attempts := 0
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+	start := time.Now()
+
+	err := call(context.Background(), invoker(&attempts, []error{unavailable}, metadata.Pairs(grpcretry.PushbackKey, "0")),
+		retryable.WithDelay(time.Hour))
+	if err != nil || attempts != 2 {
+		t.Errorf("Expected success on the second attempt, got %v after %d attempts", err, attempts)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected to retry at once, took %v", elapsed)
+	}
+}
+
+// TestUnaryClientInterceptorNegativePushback tests that a negative pushback stops the retries.
+func TestUnaryClientInterceptorNegativePushback(t *testing.T) {
+	attempts := 0
+	unavailable := status.Error(codes.Unavailable, "unavailable")
+
+	err := call(context.Background(), invoker(&attempts, []error{unavailable}, metadata.Pairs(grpcretry.PushbackKey, "-1")),
+		retryable.WithDelay(time.Millisecond))
+	if err != unavailable || attempts != 1 {
+		t.Errorf("Expected the server to stop the retries, got %v after %d attempts", err, attempts)
+	}
+}
+
+// TestUnaryClientInterceptorCanceled tests that no attempt is made once the context is done.
+func TestUnaryClientInterceptorCanceled(t *testing.T) {
+	attempts := 0
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := call(ctx, invoker(&attempts, nil))
+	if status.Code(err) != codes.Canceled || attempts != 0 {
+		t.Errorf("Expected a canceled status without attempts, got %v after %d attempts", err, attempts)
+	}
+}
@@ -0,0 +1,79 @@
+package retryable
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// RetryHedged executes the provided function, starting another attempt alongside whenever hedgeDelay
+// elapses without a result or an attempt fails with a retryable error, up to the maximum number of
+// attempts. The first success is returned and the other attempts are canceled through their context; only
+// the maximum number of attempts and the classification of errors apply among the options.
+func RetryHedged[T any](ctx context.Context, fn func(context.Context) (T, error), hedgeDelay time.Duration, opts ...Option) (T, error) {
+	c := newConfig(opts)
+	if RetriesDisabled(ctx) || c.maxAttempts < 1 {
+		c.maxAttempts = 1
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // cancels the attempts still in flight
+
+	type result struct {
+		value T
+		err   error
+	}
+	results := make(chan result)
+	started, running := 0, 0
+	start := func() {
+		started++
+		running++
+		go func() {
+			value, err := fn(ctx)
+			select {
+			case results <- result{value, err}:
+			case <-ctx.Done():
+			}
+		}()
+	}
+
+	var zero T
+	var lastErr error
+	succeeded := false
+	if countStart() {
+		defer func() { countEnd(started, succeeded) }()
+	}
+	start()
+	timer := time.NewTimer(hedgeDelay)
+	defer timer.Stop()
+	for {
+		select {
+		case r := <-results:
+			running--
+			if r.err == nil {
+				succeeded = true
+				return r.value, nil
+			}
+			lastErr = r.err
+			if halt, _ := c.classify(r.err); halt {
+				return zero, &RetryError{Outcome: OutcomeNonRetryable, Attempts: started, Err: r.err}
+			}
+			if started < c.maxAttempts {
+				start()
+				timer.Reset(hedgeDelay)
+			} else if running == 0 {
+				return zero, &RetryError{Outcome: OutcomeMaxAttempts, Attempts: started, Err: lastErr}
+			}
+		case <-timer.C:
+			if started < c.maxAttempts {
+				start()
+				timer.Reset(hedgeDelay)
+			}
+		case <-ctx.Done():
+			outcome := OutcomeCanceled
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				outcome = OutcomeDeadlineExceeded
+			}
+			return zero, &RetryError{Outcome: outcome, Attempts: started, Err: errors.Join(ctx.Err(), lastErr)}
+		}
+	}
+}
@@ -0,0 +1,69 @@
+package retryable_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestRetryHedged tests that once a fast hedged attempt wins, the slow one is canceled and no other attempt is started.
+func TestRetryHedged(t *testing.T) {
+	var started atomic.Int32
+	slowCanceled := make(chan error, 1)
+	value, err := retryable.RetryHedged(context.Background(), func(ctx context.Context) (string, error) {
+		if started.Add(1) == 1 {
+			<-ctx.Done()
+			slowCanceled <- ctx.Err()
+			return "", ctx.Err()
+		}
+		return "fast", nil
+	}, 10*time.Millisecond, retryable.WithMaxAttempts(5))
+
+	if value != "fast" || err != nil {
+		t.Fatalf("Expected the fast attempt to win, got %q, %v", value, err)
+	}
+	select {
+	case err := <-slowCanceled:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Expected the slow attempt to be canceled, got %v", err)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Expected the slow attempt to be canceled")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if n := started.Load(); n != 2 {
+		t.Errorf("Expected no attempt to start after the success, got %d attempts", n)
+	}
+}
+
+// TestRetryHedgedExhausted tests that a failing attempt triggers the next one immediately until the attempts run out.
+func TestRetryHedgedExhausted(t *testing.T) {
+	var started atomic.Int32
+	_, err := retryable.RetryHedged(context.Background(), func(context.Context) (int, error) {
+		started.Add(1)
+		return 0, errors.New("down")
+	}, 1*time.Hour, retryable.WithMaxAttempts(3))
+
+	if !errors.Is(err, retryable.ErrMaxAttemptsReached) || started.Load() != 3 {
+		t.Errorf("Expected 3 failed attempts, got %v after %d attempts", err, started.Load())
+	}
+}
+
+// TestRetryHedgedRetriesDisabled tests that a context with retries disabled gets a single attempt, without hedging.
+func TestRetryHedgedRetriesDisabled(t *testing.T) {
+	var started atomic.Int32
+	ctx := retryable.WithRetriesDisabled(context.Background())
+	_, err := retryable.RetryHedged(ctx, func(ctx context.Context) (int, error) {
+		started.Add(1)
+		time.Sleep(5 * time.Millisecond)
+		return 0, errors.New("down")
+	}, 1*time.Millisecond, retryable.WithMaxAttempts(3))
+
+	if !errors.Is(err, retryable.ErrMaxAttemptsReached) || started.Load() != 1 {
+		t.Errorf("Expected a single failed attempt, got %v after %d attempts", err, started.Load())
+	}
+}
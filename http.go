@@ -0,0 +1,189 @@
+package retryable
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrRetryableResponse is wrapped by the error of an attempt of a RoundTripper that received a response
+// worth retrying, so a check set with WithRetryIf can tell it apart from a transport error.
+var ErrRetryableResponse = errors.New("retryable: retryable response")
+
+// RetryableHTTPStatuses lists the registered statuses that IsRetryableHTTPStatus considers transient, as a
+// starting point for a custom list, e.g. checked with slices.Contains in a function given to WithRetryIf.
+// Changing it does not change IsRetryableHTTPStatus.
+var RetryableHTTPStatuses = []int{
+	http.StatusRequestTimeout,
+	http.StatusTooEarly,
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+	http.StatusHTTPVersionNotSupported,
+	http.StatusVariantAlsoNegotiates,
+	http.StatusInsufficientStorage,
+	http.StatusLoopDetected,
+	http.StatusNotExtended,
+	http.StatusNetworkAuthenticationRequired,
+}
+
+// IsRetryableHTTPStatus reports whether a response with the status code is worth retrying: 408, 425, 429
+// and the 5xx statuses other than 501 Not Implemented.
+func IsRetryableHTTPStatus(code int) bool {
+	switch code {
+	case http.StatusRequestTimeout, http.StatusTooEarly, http.StatusTooManyRequests:
+		return true
+	case http.StatusNotImplemented:
+		return false
+	}
+	return code >= 500 && code <= 599
+}
+
+// roundTripper is the http.RoundTripper returned by NewRoundTripper.
+type roundTripper struct {
+	next http.RoundTripper
+	opts []Option
+}
+
+// NewRoundTripper returns an http.RoundTripper retrying the requests sent through next, or
+// http.DefaultTransport if nil, on transport errors, io.EOF included, and on the statuses reported by
+// IsRetryableHTTPStatus, returning the last response when it gives up on one. A request with a body is sent
+// once unless it has GetBody, and WithAttemptTimeout bounds each response until its body is closed.
+func NewRoundTripper(next http.RoundTripper, opts ...Option) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &roundTripper{next: next, opts: opts}
+}
+
+// WithRetryOnBody makes a RoundTripper also retry the 2xx responses whose body, read up to maxSize bytes,
+// makes shouldRetryBody return true; a larger body is not inspected. maxSize must be at least 1.
+func WithRetryOnBody(shouldRetryBody func(body []byte) bool, maxSize int64) Option {
+	return func(c *config) {
+		if maxSize < 1 {
+			c.invalid("max body size must be at least 1, got %d", maxSize)
+		}
+		c.retryBody = shouldRetryBody
+		c.retryBodyLimit = maxSize
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil && req.Body != http.NoBody && req.GetBody == nil {
+		// The body cannot be replayed, so the request is sent once.
+		return rt.next.RoundTrip(req)
+	}
+
+	// A transport error matching io.EOF usually means the server closed an idle connection, which is transient.
+	c := newConfig(append([]Option{WithRetryOnEOF()}, rt.opts...))
+	// The attempt timeout is applied here rather than by the loop, to cancel it when the body is closed.
+	attemptTimeout := c.attemptTimeout
+	c.attemptTimeout = nil
+	sent := 0
+	var last *http.Response
+	res := retryLoop(req.Context(), func(ctx context.Context) (*http.Response, error) {
+		if last != nil {
+			discard(last)
+			last = nil
+		}
+		attemptReq := req.Clone(ctx)
+		if sent > 0 && req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq.Body = body
+		}
+		sent++
+
+		cancel := context.CancelFunc(func() {})
+		if attemptTimeout != nil && !(sent == c.maxAttempts && c.finalAttempt != nil) {
+			var attemptCtx context.Context
+			attemptCtx, cancel = context.WithTimeout(ctx, attemptTimeout())
+			attemptReq = attemptReq.WithContext(attemptCtx)
+		}
+		resp, err := rt.next.RoundTrip(attemptReq)
+		if err != nil {
+			cancel()
+			return nil, err
+		}
+		resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+		if err := c.checkResponse(resp); err != nil {
+			if !errors.Is(err, ErrRetryableResponse) {
+				// The body failed to read and is closed, so the response cannot be returned.
+				return nil, err
+			}
+			last = resp
+			return resp, err
+		}
+		return resp, nil
+	}, c)
+
+	if res.Err == nil {
+		return res.Value, nil
+	}
+	if last != nil {
+		if req.Context().Err() == nil {
+			return last, nil
+		}
+		discard(last)
+	}
+	return nil, res.Err
+}
+
+// checkResponse returns an error wrapping ErrRetryableResponse if the response is worth retrying,
+// buffering its body when WithRetryOnBody is set. It closes the body if reading it fails.
+func (c *config) checkResponse(resp *http.Response) error {
+	if IsRetryableHTTPStatus(resp.StatusCode) {
+		return fmt.Errorf("%w: status %s", ErrRetryableResponse, resp.Status)
+	}
+	if c.retryBody == nil || resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil
+	}
+
+	buf, err := io.ReadAll(io.LimitReader(resp.Body, c.retryBodyLimit+1))
+	if err != nil {
+		resp.Body.Close()
+		return err
+	}
+	if int64(len(buf)) > c.retryBodyLimit {
+		resp.Body = struct {
+			io.Reader
+			io.Closer
+		}{io.MultiReader(bytes.NewReader(buf), resp.Body), resp.Body}
+		return nil
+	}
+	resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(buf))
+	if c.retryBody(buf) {
+		return fmt.Errorf("%w: body of status %s", ErrRetryableResponse, resp.Status)
+	}
+	return nil
+}
+
+// cancelOnClose is a response body that cancels the context of its attempt when closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and cancels the context of the attempt.
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// maxDiscard bounds how much of the body of a discarded response is drained to reuse its connection.
+const maxDiscard = 64 << 10
+
+// discard drains and closes the body of a response that is not returned, so its connection can be reused.
+func discard(resp *http.Response) {
+	_, _ = io.CopyN(io.Discard, resp.Body, maxDiscard)
+	resp.Body.Close()
+}
@@ -0,0 +1,190 @@
+package retryable_test
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestRoundTripperRetriesStatus tests that retryable statuses are retried and the request body replayed.
+func TestRoundTripperRetriesStatus(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if string(body) != "payload" {
+			t.Errorf("Expected the request body to be replayed, got %q", body)
+		}
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: retryable.NewRoundTripper(nil, retryable.WithDelay(1*time.Millisecond))}
+	resp, err := client.Post(server.URL, "text/plain", strings.NewReader("payload"))
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "ok" || calls.Load() != 3 {
+		t.Errorf("Expected success after 3 calls, got status %d, body %q and %d calls", resp.StatusCode, body, calls.Load())
+	}
+}
+
+// TestRoundTripperReturnsLastResponse tests that the last retryable response is returned once attempts run out.
+func TestRoundTripperReturnsLastResponse(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: retryable.NewRoundTripper(nil, retryable.WithMaxAttempts(2), retryable.WithDelay(1*time.Millisecond))}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || calls.Load() != 2 {
+		t.Errorf("Expected the last 429 response after 2 calls, got status %d and %d calls", resp.StatusCode, calls.Load())
+	}
+}
+
+// TestRoundTripperRetryOnBody tests that an error envelope in a 2xx body is retried and that the
+// returned body is still readable.
+func TestRoundTripperRetryOnBody(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 2 {
+			_, _ = io.WriteString(w, `{"error":"try again"}`)
+			return
+		}
+		_, _ = io.WriteString(w, `{"result":42}`)
+	}))
+	defer server.Close()
+
+	shouldRetry := func(body []byte) bool {
+		return strings.Contains(string(body), `"error"`)
+	}
+	client := &http.Client{Transport: retryable.NewRoundTripper(nil, retryable.WithDelay(1*time.Millisecond), retryable.WithRetryOnBody(shouldRetry, 1024))}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if string(body) != `{"result":42}` || calls.Load() != 2 {
+		t.Errorf("Expected the second body after 2 calls, got %q and %d calls", body, calls.Load())
+	}
+}
+
+// TestRoundTripperRetryOnBodyTooLarge tests that a body larger than the cap is handed over uninspected and intact.
+func TestRoundTripperRetryOnBodyTooLarge(t *testing.T) {
+	large := strings.Repeat("x", 100)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, large)
+	}))
+	defer server.Close()
+
+	inspected := false
+	shouldRetry := func([]byte) bool {
+		inspected = true
+		return true
+	}
+	client := &http.Client{Transport: retryable.NewRoundTripper(nil, retryable.WithRetryOnBody(shouldRetry, 10))}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if inspected || string(body) != large {
+		t.Errorf("Expected the large body to be passed through uninspected, got %d bytes, inspected %v", len(body), inspected)
+	}
+}
+
+// roundTripFunc adapts a function to http.RoundTripper.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+// RoundTrip implements http.RoundTripper.
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// TestRoundTripperRetryOnBodyReadError tests that a response whose body fails to read is not returned
+// when the retries give up, the read error being returned instead.
+func TestRoundTripperRetryOnBodyReadError(t *testing.T) {
+	errRead := errors.New("connection reset while reading")
+	calls := 0
+	next := roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Status:     "200 OK",
+			Body:       io.NopCloser(iotest.ErrReader(errRead)),
+			Request:    req,
+		}, nil
+	})
+
+	rt := retryable.NewRoundTripper(next, retryable.WithMaxAttempts(2), retryable.WithDelay(1*time.Millisecond),
+		retryable.WithRetryOnBody(func([]byte) bool { return false }, 1024))
+	req, _ := http.NewRequest(http.MethodGet, "http://example.invalid", nil)
+	resp, err := rt.RoundTrip(req)
+	if resp != nil || !errors.Is(err, errRead) || calls != 2 {
+		t.Errorf("Expected the read error after 2 calls, got %v, %v after %d calls", resp, err, calls)
+	}
+}
+
+// TestIsRetryableHTTPStatus tests the statuses considered transient, and that the default list agrees with them.
+func TestIsRetryableHTTPStatus(t *testing.T) {
+	retryableCodes := []int{408, 425, 429, 500, 502, 503, 504, 599}
+	otherCodes := []int{200, 301, 400, 401, 404, 409, 501, 600}
+	for _, code := range retryableCodes {
+		if !retryable.IsRetryableHTTPStatus(code) {
+			t.Errorf("Expected status %d to be retryable", code)
+		}
+	}
+	for _, code := range otherCodes {
+		if retryable.IsRetryableHTTPStatus(code) {
+			t.Errorf("Expected status %d not to be retryable", code)
+		}
+	}
+	for _, code := range retryable.RetryableHTTPStatuses {
+		if !retryable.IsRetryableHTTPStatus(code) {
+			t.Errorf("Expected listed status %d to be retryable", code)
+		}
+	}
+}
+
+// TestRoundTripperAttemptTimeoutStreamedBody tests that the attempt timeout does not cut a streamed body
+// short once RoundTrip has returned.
+func TestRoundTripperAttemptTimeoutStreamedBody(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "hello")
+		w.(http.Flusher).Flush()
+		time.Sleep(50 * time.Millisecond)
+		_, _ = io.WriteString(w, ", world")
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: retryable.NewRoundTripper(nil, retryable.WithAttemptTimeout(1*time.Second))}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil || string(body) != "hello, world" {
+		t.Errorf("Expected the whole streamed body, got %q with %v", body, err)
+	}
+}
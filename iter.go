@@ -0,0 +1,38 @@
+package retryable
+
+import (
+	"context"
+	"iter"
+	"time"
+)
+
+// Attempts returns an iterator over the attempts of a retry loop, yielding the attempt number with a wait
+// function to call after a failure, which sleeps for the configured delay and reports whether another
+// attempt follows.
+//
+//	for attempt, wait := range retryable.Attempts(ctx, retryable.WithMaxAttempts(5)) {
+//		if err = doSomething(); err == nil || !wait() {
+//			break
+//		}
+//	}
+func Attempts(ctx context.Context, opts ...Option) iter.Seq2[int, func() bool] {
+	c := newConfig(opts)
+	if RetriesDisabled(ctx) || c.maxAttempts < 1 {
+		c.maxAttempts = 1
+	}
+	return func(yield func(int, func() bool) bool) {
+		var prev time.Duration
+		for attempt := 1; attempt <= c.maxAttempts && ctx.Err() == nil; attempt++ {
+			wait := func() bool {
+				if attempt == c.maxAttempts {
+					return false
+				}
+				prev = c.nextDelay(attempt, prev)
+				return c.sleep(ctx, prev) == nil
+			}
+			if !yield(attempt, wait) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package retryable_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestAttempts tests driving a retry loop with the iterator until success.
+func TestAttempts(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+
+	var err error
+	var last int
+	for attempt, wait := range retryable.Attempts(context.Background(),
+		retryable.WithMaxAttempts(5),
+		retryable.WithExponentialBackoff(1*time.Second, 2, 0),
+		retryable.WithClock(clock),
+	) {
+		last = attempt
+		if err = failUntil(attempt, 3); err == nil || !wait() {
+			break
+		}
+	}
+
+	if err != nil || last != 3 {
+		t.Errorf("Expected success on the third attempt, got attempt %d with error %v", last, err)
+	}
+	if elapsed := clock.Now().Sub(start); elapsed != 3*time.Second {
+		t.Errorf("Expected to wait 1s then 2s, waited %v", elapsed)
+	}
+}
+
+// TestAttemptsExhausted tests that wait reports the last attempt without sleeping.
+func TestAttemptsExhausted(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+
+	attempts := 0
+	for _, wait := range retryable.Attempts(context.Background(), retryable.WithMaxAttempts(3), retryable.WithDelay(1*time.Second), retryable.WithClock(clock)) {
+		attempts++
+		if !wait() {
+			break
+		}
+	}
+
+	if attempts != 3 || clock.Now().Sub(start) != 2*time.Second {
+		t.Errorf("Expected 3 attempts with 2 waits, got %d attempts after %v", attempts, clock.Now().Sub(start))
+	}
+}
+
+// TestAttemptsContextCanceled tests that a canceled context interrupts the wait and ends the iteration.
+func TestAttemptsContextCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	attempts := 0
+	for _, wait := range retryable.Attempts(ctx, retryable.WithMaxAttempts(10), retryable.WithDelay(1*time.Hour)) {
+		attempts++
+		cancel()
+		if wait() {
+			t.Errorf("Expected wait to report the cancellation")
+		}
+	}
+
+	if attempts != 1 {
+		t.Errorf("Expected a single attempt, got %d", attempts)
+	}
+}
+
+// failUntil fails until the given attempt.
+func failUntil(attempt, success int) error {
+	if attempt < success {
+		return errors.New("not yet")
+	}
+	return nil
+}
+
+// TestAttemptsRetriesDisabled tests that a context with retries disabled yields a single attempt.
+func TestAttemptsRetriesDisabled(t *testing.T) {
+	attempts := 0
+	ctx := retryable.WithRetriesDisabled(context.Background())
+	for _, wait := range retryable.Attempts(ctx, retryable.WithMaxAttempts(3), retryable.WithClock(newFakeClock())) {
+		attempts++
+		if !wait() {
+			break
+		}
+	}
+
+	if attempts != 1 {
+		t.Errorf("Expected a single attempt, got %d", attempts)
+	}
+}
@@ -0,0 +1,542 @@
+package retryable
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ignoreContext adapts a function without context to the signature used by the retry loop.
+func ignoreContext[T any](fn func() (T, error)) func(context.Context) (T, error) {
+	return func(context.Context) (T, error) {
+		return fn()
+	}
+}
+
+// loopState tracks a single run of the retry loop.
+type loopState[T any] struct {
+	c        *config
+	res      RetryResult[T]
+	err      error
+	firstErr error
+	start    time.Time
+	stalls   int
+	category string
+	delay    time.Duration
+
+	// totalDelay is the sum of the delays waited so far.
+	totalDelay time.Duration
+
+	// progressionStart is the number of attempts made before the backoff progression was last reset.
+	progressionStart int
+
+	// history records the failed attempts, with WithHistoryAwareDelay.
+	history []AttemptRecord
+
+	// tally counts the distinct error messages, in order of first occurrence, with WithGiveUpSummary.
+	tally []errorCount
+
+	// dirty is set while the resources of a failed attempt have not been cleaned up.
+	dirty bool
+
+	// finished is set once the loop has terminated, telling a panic of the function apart in GlobalStats.
+	finished bool
+
+	// started is the number of attempts started, including one whose function panicked, for GlobalStats.
+	started int
+}
+
+// retryLoop runs the retry loop shared by the option-based functions.
+func retryLoop[T any](ctx context.Context, fn func(context.Context) (T, error), c *config) RetryResult[T] {
+	s := &loopState[T]{c: c}
+	if countStart() {
+		// Deferred so that a panicking function does not leave the loop counted in flight.
+		defer func() { countEnd(s.started, s.finished && s.res.Outcome == OutcomeSuccess) }()
+	}
+	if RetriesDisabled(ctx) {
+		c.maxAttempts = 1
+	}
+	if c.tracer != nil {
+		var span Span
+		ctx, span = c.tracer.Start(ctx, retrySpanName)
+		defer func() {
+			span.SetAttribute("retry.attempts", s.res.Attempts)
+			span.SetAttribute("retry.outcome", s.res.Outcome.String())
+			if s.res.Err != nil {
+				span.RecordError(s.res.Err)
+			}
+			span.End()
+		}()
+	}
+
+	if err := c.strictCheck(ctx); err != nil {
+		s.start = c.clock.Now()
+		return s.fail(OutcomeInvalidConfig, err)
+	}
+	c.maxAttempts = max(c.maxAttempts, 1)
+
+	var prev T
+	// probing is set while a probe admitted by the circuit breaker has not reported its outcome.
+	probing := false
+	if c.breaker != nil {
+		defer func() {
+			if probing {
+				c.breaker.abandonProbe()
+			}
+		}()
+	}
+	s.start = c.clock.Now()
+	if c.store != nil && c.streak == nil {
+		failures, slept, err := c.resumeBackoff(ctx)
+		s.res.SleepTime += slept
+		if err != nil {
+			if errors.Is(err, ErrShutdown) {
+				return s.fail(OutcomeShutdown, ErrShutdown)
+			}
+			return s.canceled(ctx)
+		}
+		// Continue the persisted progression: the first failure of the call is failure n+1.
+		s.progressionStart = -failures
+	}
+	for attempt := 1; attempt <= c.maxAttempts; attempt++ {
+		if ctx.Err() != nil {
+			return s.canceled(ctx)
+		}
+		if deadline, ok := ctx.Deadline(); ok && !c.clock.Now().Before(deadline) {
+			// The deadline has passed even though the context may not be marked done yet.
+			s.res.ctxErr = context.DeadlineExceeded
+			return s.fail(OutcomeDeadlineExceeded, errors.Join(context.DeadlineExceeded, s.err))
+		}
+		if c.isShutdown() {
+			return s.fail(OutcomeShutdown, cmp.Or(s.err, ErrShutdown))
+		}
+
+		if c.earlyExit != nil {
+			if v, ok := c.earlyExit(); ok {
+				if value, ok := v.(T); ok {
+					s.res.Value = value
+					return s.finish(OutcomeSuccess)
+				}
+			}
+		}
+
+		if c.healthy != nil && !c.healthy() {
+			return s.fail(OutcomeUnhealthy, cmp.Or(s.err, ErrDependencyUnhealthy))
+		}
+		probe := false
+		if c.breaker != nil {
+			var ok bool
+			if ok, probe = c.breaker.allow(c.clock.Now()); !ok {
+				return s.fail(OutcomeCircuitOpen, cmp.Or(s.err, ErrCircuitOpen))
+			}
+			probing = probe
+		}
+
+		if err := c.acquire(ctx); err != nil {
+			if errors.Is(err, ErrShutdown) {
+				return s.fail(OutcomeShutdown, cmp.Or(s.err, ErrShutdown))
+			}
+			return s.canceled(ctx)
+		}
+		if c.liveAttempts != nil {
+			c.liveAttempts.Add(1)
+		}
+		if c.beforeAttempt != nil {
+			c.beforeAttempt(attempt)
+		}
+		c.logAttemptStart(attempt)
+		attemptStart := c.clock.Now()
+		s.started = attempt
+		value, err := callAttempt(ctx, c, attempt, fn)
+		duration := c.clock.Now().Sub(attemptStart)
+		s.res.ExecutionTime += duration
+		if err != nil && c.acceptResult != nil && c.acceptResult(value) {
+			err = nil
+		}
+		s.record(attempt, value, err)
+		if c.breaker != nil {
+			c.breaker.record(c.clock.Now(), probe, err)
+			probing = false
+		}
+		if c.latencies != nil {
+			c.latencies.record(duration)
+		}
+		if c.afterAttempt != nil {
+			c.afterAttempt(attempt, err, duration)
+		}
+		if err == nil {
+			if c.streak != nil {
+				c.streak.succeed(c.resetAfterSuccesses)
+			}
+			c.saveBackoff(0)
+			s.res.SuccessLatency = duration
+			return s.finish(OutcomeSuccess)
+		}
+
+		halt, classification := c.classify(err)
+		if !halt && c.categorize != nil {
+			category := c.categorize(err)
+			if attempt > 1 && category != s.category {
+				halt, classification = true, "non-retryable:category-change"
+			}
+			s.category = category
+		}
+		if c.onRetryEvent != nil {
+			c.onRetryEvent(RetryEvent{Attempt: attempt, Err: err, Duration: duration, Classification: classification})
+		}
+		if halt {
+			return s.fail(OutcomeNonRetryable, err)
+		}
+		if c.sameProgress != nil && attempt > 1 {
+			if c.sameProgress(prev, value) {
+				s.stalls++
+			} else {
+				s.stalls = 0
+			}
+			if s.stalls >= c.maxStalls {
+				return s.fail(OutcomeNoProgress, err)
+			}
+		}
+		prev = value
+		if c.historyDelay != nil {
+			s.history = append(s.history, AttemptRecord{Attempt: attempt, Err: err, Duration: duration})
+		}
+		hintedDelay, reset := backoffHint(err)
+		if reset {
+			s.progressionStart = attempt - 1
+			if c.streak != nil {
+				c.streak.reset()
+			}
+		}
+		backoffAttempt := attempt - s.progressionStart
+		if c.streak != nil {
+			backoffAttempt = c.streak.fail(c.clock.Now(), c.streakDecay)
+		}
+		c.saveBackoff(backoffAttempt)
+		if attempt == c.maxAttempts {
+			break
+		}
+		delay := hintedDelay
+		if delay <= 0 && !(attempt == 1 && c.firstRetryImmediate) {
+			if c.historyDelay != nil {
+				delay = max(c.historyDelay(s.history[:len(s.history):len(s.history)]), 0)
+			} else {
+				delay = c.nextDelay(backoffAttempt, s.delay)
+			}
+		}
+		if c.delayModifier != nil {
+			requested := delay
+			delay = max(c.delayModifier(delay), 0)
+			c.delayAdjusted(requested, delay, "modified")
+		}
+		s.delay = delay
+		if c.shouldContinue != nil && !c.shouldContinue(RetryState{
+			Attempt:    attempt,
+			Elapsed:    c.clock.Now().Sub(s.start),
+			LastError:  err,
+			TotalDelay: s.totalDelay,
+			NextDelay:  delay,
+		}) {
+			c.delayAdjusted(delay, 0, "stopped")
+			return s.fail(OutcomeStopped, err)
+		}
+		if remaining := c.budget - c.clock.Now().Sub(s.start); c.budget > 0 && delay > remaining {
+			if !c.truncateDelay || remaining <= 0 {
+				c.delayAdjusted(delay, 0, "budget")
+				return s.exhausted(OutcomeDeadlineExceeded)
+			}
+			c.delayAdjusted(delay, remaining, "budget")
+			delay = remaining
+		}
+		if deadline, ok := ctx.Deadline(); ok && c.clock.Now().Add(delay+duration).After(deadline) {
+			// The next attempt, estimated to last as long as this one, could not finish in time.
+			c.delayAdjusted(delay, 0, "deadline")
+			s.res.ctxErr = context.DeadlineExceeded
+			return s.fail(OutcomeDeadlineExceeded, errors.Join(context.DeadlineExceeded, err))
+		}
+
+		if c.cleanup != nil {
+			c.cleanup()
+			s.dirty = false
+		}
+		c.logRetry(attempt, err, delay)
+		if c.recordCategory != nil {
+			c.recordCategory(err)
+		}
+		if attempt == 1 && c.onFirstRetry != nil {
+			c.onFirstRetry(err)
+		}
+		sleepStart := c.clock.Now()
+		sleepErr := c.sleep(ctx, delay)
+		slept := c.clock.Now().Sub(sleepStart)
+		s.res.SleepTime += slept
+		if sleepErr != nil {
+			if errors.Is(sleepErr, ErrShutdown) {
+				c.delayAdjusted(delay, slept, "shutdown")
+				return s.fail(OutcomeShutdown, cmp.Or(s.err, ErrShutdown))
+			}
+			c.delayAdjusted(delay, slept, "canceled")
+			return s.canceled(ctx)
+		}
+		s.totalDelay += delay
+	}
+	return s.exhausted(OutcomeMaxAttempts)
+}
+
+// callAttempt calls the function once, within its own span if tracing is enabled and bounded by the
+// per-attempt timeout if one is configured, unless the final attempt is overridden. It releases the slot
+// of the concurrency limit taken before it.
+func callAttempt[T any](ctx context.Context, c *config, attempt int, fn func(context.Context) (T, error)) (value T, err error) {
+	defer c.release()
+	if c.tracer != nil {
+		var span Span
+		ctx, span = c.tracer.Start(ctx, attemptSpanName)
+		span.SetAttribute("retry.attempt", attempt)
+		defer func() {
+			if err != nil {
+				span.RecordError(err)
+			}
+			span.End()
+		}()
+	}
+	if c.maxAttempts != unlimitedAttempts {
+		ctx = context.WithValue(ctx, remainingAttemptsKey{}, c.maxAttempts-attempt)
+	}
+	if attempt == c.maxAttempts && c.finalAttempt != nil {
+		return fn(c.finalAttempt(ctx))
+	}
+	if c.attemptTimeout != nil {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.attemptTimeout())
+		defer cancel()
+	}
+	return fn(ctx)
+}
+
+// record stores the outcome of an attempt.
+func (s *loopState[T]) record(attempt int, value T, err error) {
+	s.res.Value = value
+	s.res.Attempts = attempt
+	s.err = err
+	s.dirty = err != nil
+	if err == nil {
+		return
+	}
+	if s.firstErr == nil {
+		s.firstErr = err
+	}
+	if s.c.giveUpSummary {
+		s.count(err)
+	}
+	if max := s.c.maxErrors; max > 0 && len(s.res.Errors) >= max {
+		// Keep the most recent errors only, reusing the slice so it never grows past the cap.
+		n := copy(s.res.Errors, s.res.Errors[len(s.res.Errors)-max+1:])
+		s.res.Errors = s.res.Errors[:n]
+	}
+	s.res.Errors = append(s.res.Errors, err)
+}
+
+// finish records the termination of the loop.
+func (s *loopState[T]) finish(outcome Outcome) RetryResult[T] {
+	s.res.Outcome = outcome
+	s.res.Elapsed = s.c.clock.Now().Sub(s.start)
+	s.finished = true
+	return s.res
+}
+
+// fail terminates the loop with the given outcome and error.
+func (s *loopState[T]) fail(outcome Outcome, err error) RetryResult[T] {
+	if s.dirty && s.c.finalCleanup && s.c.cleanup != nil {
+		s.c.cleanup()
+	}
+	retryErr := &RetryError{Outcome: outcome, Attempts: s.res.Attempts, Err: err}
+	if s.c.aggregateErrors {
+		retryErr.Errors = s.res.Errors
+	}
+	s.res.Err = retryErr
+	res := s.finish(outcome)
+	if s.c.giveUpSummary && !s.c.quiet && logEnabled() {
+		s.logSummary()
+	}
+	return res
+}
+
+// errorCount is the number of occurrences of an error message.
+type errorCount struct {
+	msg string
+	n   int
+}
+
+// count adds an error to the tally.
+func (s *loopState[T]) count(err error) {
+	msg := err.Error()
+	for i := range s.tally {
+		if s.tally[i].msg == msg {
+			s.tally[i].n++
+			return
+		}
+	}
+	s.tally = append(s.tally, errorCount{msg: msg, n: 1})
+}
+
+// logSummary logs the summary line of WithGiveUpSummary.
+func (s *loopState[T]) logSummary() {
+	seen := make([]string, len(s.tally))
+	for i, e := range s.tally {
+		seen[i] = fmt.Sprintf("%q x%d", e.msg, e.n)
+	}
+	last := "none"
+	if s.err != nil {
+		last = strconv.Quote(s.err.Error())
+	}
+	logf("Gave up after %d attempt(s) over %v; last error: %s; errors seen: %s; reason: %s",
+		s.res.Attempts, s.res.Elapsed, last, cmp.Or(strings.Join(seen, ", "), "none"), s.reason())
+}
+
+// giveUpReasons maps the outcomes to the reasons logged by WithGiveUpSummary.
+var giveUpReasons = map[Outcome]string{
+	OutcomeMaxAttempts:      "max_attempts",
+	OutcomeNonRetryable:     "non_retryable",
+	OutcomeCanceled:         "context_cancelled",
+	OutcomeDeadlineExceeded: "deadline_exceeded",
+	OutcomeNoProgress:       "no_progress",
+	OutcomeShutdown:         "shutdown",
+	OutcomeStopped:          "stopped",
+	OutcomeCircuitOpen:      "circuit_open",
+	OutcomeUnhealthy:        "dependency_unhealthy",
+	OutcomeInvalidConfig:    "invalid_config",
+}
+
+// reason returns the reason the loop gave up, telling a time budget used up from a context deadline.
+func (s *loopState[T]) reason() string {
+	if s.res.Outcome == OutcomeDeadlineExceeded && s.res.ctxErr == nil {
+		return "budget_exhausted"
+	}
+	return giveUpReasons[s.res.Outcome]
+}
+
+// exhausted terminates the loop after running out of attempts or time, reporting the first or the
+// last error as configured.
+func (s *loopState[T]) exhausted(outcome Outcome) RetryResult[T] {
+	if s.c.returnFirstError {
+		return s.fail(outcome, s.firstErr)
+	}
+	return s.fail(outcome, s.err)
+}
+
+// canceled terminates the loop because the context is done, joining its error with the last one.
+func (s *loopState[T]) canceled(ctx context.Context) RetryResult[T] {
+	outcome := OutcomeCanceled
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		outcome = OutcomeDeadlineExceeded
+	}
+	s.res.ctxErr = ctx.Err()
+	return s.fail(outcome, errors.Join(ctx.Err(), s.err))
+}
+
+// classify classifies an error that is not marked with Permanent or Retryable: an error matching io.EOF
+// halts unless WithRetryOnEOF is set, then the check of WithRetryIf decides, if any.
+func (c *config) classify(err error) (halt bool, classification string) {
+	switch {
+	case !c.retryEOF && errors.Is(err, io.EOF):
+		return classify(err, "eof", func(error) bool { return true })
+	case c.isRetryable != nil:
+		return classify(err, "check", func(err error) bool { return !c.isRetryable(err) })
+	default:
+		return classify(err, "default", func(error) bool { return false })
+	}
+}
+
+// nextDelay computes the delay to wait after the given failed attempt, or the given length of the failure
+// streak with WithStatefulBackoff, given the previous delay of the loop, zero before the first one.
+func (c *config) nextDelay(attempt int, prev time.Duration) time.Duration {
+	delay := c.backoff.Delay(attempt)
+	if c.monotonicJitter {
+		if c.jitter > 0 {
+			delay = applyPositiveJitter(delay, c.jitter, c.randFloat())
+		}
+		delay = max(delay, prev)
+	} else if c.jitter > 0 {
+		delay = applyJitter(delay, c.jitter, c.randFloat())
+	}
+	if c.maxGrowthFactor > 0 && prev > 0 {
+		delay = min(delay, time.Duration(c.maxGrowthFactor*float64(prev)))
+	}
+	if c.delayRounding > 0 {
+		delay = delay.Round(c.delayRounding)
+	}
+	return delay
+}
+
+// sleep waits for the delay with the clock, returning early with the context error or with ErrShutdown
+// when the Retrier running the loop is shut down.
+func (c *config) sleep(ctx context.Context, delay time.Duration) error {
+	if c.shutdown == nil {
+		return c.clock.Sleep(ctx, delay)
+	}
+
+	sleepCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go func() {
+		select {
+		case <-c.shutdown:
+			cancel(ErrShutdown)
+		case <-sleepCtx.Done():
+		}
+	}()
+
+	if err := c.clock.Sleep(sleepCtx, delay); err != nil {
+		if errors.Is(context.Cause(sleepCtx), ErrShutdown) {
+			return ErrShutdown
+		}
+		return err
+	}
+	return nil
+}
+
+// isShutdown reports whether the Retrier running the loop was shut down.
+func (c *config) isShutdown() bool {
+	select {
+	case <-c.shutdown:
+		return true
+	default:
+		return false
+	}
+}
+
+// delayAdjusted calls the hook of WithOnDelayAdjusted, if any, when the actual delay differs from the
+// requested one.
+func (c *config) delayAdjusted(requested, actual time.Duration, reason string) {
+	if c.onDelayAdjusted != nil && actual != requested {
+		c.onDelayAdjusted(requested, actual, reason)
+	}
+}
+
+// logAttemptStart logs the start of an attempt with WithLogAttemptStart.
+func (c *config) logAttemptStart(attempt int) {
+	if !c.logStart || c.quiet || !logEnabled() {
+		return
+	}
+	if c.maxAttempts == unlimitedAttempts {
+		logf("Starting attempt %d...", attempt)
+		return
+	}
+	logf("Starting attempt %d/%d...", attempt, c.maxAttempts)
+}
+
+// logRetry logs a failed attempt that is about to be retried.
+func (c *config) logRetry(attempt int, err error, delay time.Duration) {
+	if c.quiet || !logEnabled() {
+		return
+	}
+	if c.maxAttempts == unlimitedAttempts {
+		logf("Attempt %d failed with an error: %v. Retrying in %v...", attempt, err, delay)
+		return
+	}
+	logf("Attempt %d/%d failed with an error: %v. Retrying in %v...", attempt, c.maxAttempts, err, delay)
+}
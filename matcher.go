@@ -0,0 +1,108 @@
+package retryable
+
+import (
+	"errors"
+	"strings"
+)
+
+// Matcher is a precompiled error classifier built once and reused across retry calls.
+// It matches an error when its message contains one of the patterns or when errors.Is
+// reports a match against one of the sentinel errors.
+// A Matcher is safe for concurrent use since it is never modified after construction.
+type Matcher struct {
+	patterns  []string
+	sentinels []error
+}
+
+// NewMatcher builds a Matcher from message patterns and sentinel errors.
+// Empty and duplicated patterns are discarded so that they are not scanned on every call.
+func NewMatcher(patterns []string, sentinels ...error) *Matcher {
+	m := &Matcher{sentinels: sentinels}
+	seen := make(map[string]struct{}, len(patterns))
+	for _, pattern := range patterns {
+		if _, ok := seen[pattern]; ok || pattern == "" {
+			continue
+		}
+		seen[pattern] = struct{}{}
+		m.patterns = append(m.patterns, pattern)
+	}
+	return m
+}
+
+// Match reports whether err is matched by any sentinel or pattern of the Matcher.
+// A nil error never matches. Match does not allocate, so its method value can be
+// passed as the predicate of RetryWithCustomCheck in hot paths.
+func (m *Matcher) Match(err error) bool {
+	if err == nil {
+		return false
+	}
+	for _, sentinel := range m.sentinels {
+		if errors.Is(err, sentinel) {
+			return true
+		}
+	}
+	msg := err.Error()
+	for _, pattern := range m.patterns {
+		if strings.Contains(msg, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// MergeErrorPatterns concatenates lists of error patterns into a single list without duplicates, keeping
+// the order of first appearance, so that shared pattern sets can be composed.
+func MergeErrorPatterns(lists ...[]string) []string {
+	var merged []string
+	for _, list := range lists {
+		merged = append(merged, list...)
+	}
+	return DedupPatterns(merged)
+}
+
+// DedupPatterns returns the patterns without duplicates, keeping the order of first appearance.
+// The input slice is returned as is when it has no duplicates.
+func DedupPatterns(patterns []string) []string {
+	seen := make(map[string]struct{}, len(patterns))
+	for i, pattern := range patterns {
+		if _, ok := seen[pattern]; ok {
+			return dedupFrom(patterns, i, seen)
+		}
+		seen[pattern] = struct{}{}
+	}
+	return patterns
+}
+
+// dedupFrom copies the patterns into a new slice, skipping the duplicates from index i onwards.
+func dedupFrom(patterns []string, i int, seen map[string]struct{}) []string {
+	deduped := append(make([]string, 0, len(patterns)-1), patterns[:i]...)
+	for _, pattern := range patterns[i+1:] {
+		if _, ok := seen[pattern]; ok {
+			continue
+		}
+		seen[pattern] = struct{}{}
+		deduped = append(deduped, pattern)
+	}
+	return deduped
+}
+
+// ClassifyAll reports how the patterns classify each error, matched like ContainsError, as "retryable",
+// "non-retryable", "conflict" when both lists match or "unmatched", to audit the lists. The errors must be
+// comparable.
+func ClassifyAll(errs []error, retryable, nonRetryable []string) map[error]string {
+	classes := make(map[error]string, len(errs))
+	for _, err := range errs {
+		isRetryable, isNonRetryable := ContainsError(err, retryable), ContainsError(err, nonRetryable)
+		switch {
+		case isRetryable && isNonRetryable:
+			classes[err] = "conflict"
+		case isRetryable:
+			classes[err] = "retryable"
+		case isNonRetryable:
+			classes[err] = "non-retryable"
+		default:
+			classes[err] = "unmatched"
+		}
+	}
+	return classes
+}
@@ -0,0 +1,116 @@
+package retryable_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestMatcherPatternsAndSentinels tests that a Matcher matches both message patterns and wrapped sentinels.
+func TestMatcherPatternsAndSentinels(t *testing.T) {
+	errUnavailable := errors.New("unavailable")
+	m := retryable.NewMatcher([]string{"timeout", "", "timeout"}, errUnavailable)
+
+	if !m.Match(errors.New("i/o timeout")) {
+		t.Errorf("Expected pattern to match")
+	}
+	if !m.Match(fmt.Errorf("call failed: %w", errUnavailable)) {
+		t.Errorf("Expected wrapped sentinel to match")
+	}
+	if m.Match(errors.New("permission denied")) || m.Match(nil) {
+		t.Errorf("Expected unrelated and nil errors to not match")
+	}
+}
+
+// TestMatcherWithCustomCheck tests reusing a Matcher as the predicate of RetryWithCustomCheck.
+func TestMatcherWithCustomCheck(t *testing.T) {
+	m := retryable.NewMatcher([]string{"temporary"})
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, errors.New("temporary error")
+		}
+		return attempts, nil
+	}
+
+	result, err := retryable.RetryWithCustomCheck(fn, 5, 1*time.Millisecond, m.Match)
+	if err != nil || result != 3 {
+		t.Errorf("Expected success on the third attempt, got %v with error %v", result, err)
+	}
+}
+
+func BenchmarkContainsError(b *testing.B) {
+	patterns := []string{"timeout", "connection reset", "unavailable", "temporary"}
+	err := fmt.Errorf("request failed: %w", errors.New("service unavailable"))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		retryable.ContainsError(err, patterns)
+	}
+}
+
+func BenchmarkMatcherMatch(b *testing.B) {
+	m := retryable.NewMatcher([]string{"timeout", "connection reset", "unavailable", "temporary"})
+	err := fmt.Errorf("request failed: %w", errors.New("service unavailable"))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		m.Match(err)
+	}
+}
+
+// TestMergeErrorPatterns tests that merged pattern lists keep the first appearance of each pattern.
+func TestMergeErrorPatterns(t *testing.T) {
+	network := []string{"timeout", "connection reset"}
+	storage := []string{"throttled", "timeout"}
+
+	merged := retryable.MergeErrorPatterns(network, storage, nil)
+	expected := []string{"timeout", "connection reset", "throttled"}
+	if fmt.Sprint(merged) != fmt.Sprint(expected) {
+		t.Errorf("Expected %v, got %v", expected, merged)
+	}
+}
+
+// TestDedupPatterns tests deduplication with and without duplicates.
+func TestDedupPatterns(t *testing.T) {
+	unique := []string{"a", "b"}
+	if got := retryable.DedupPatterns(unique); &got[0] != &unique[0] {
+		t.Errorf("Expected the input slice to be returned when it has no duplicates")
+	}
+
+	if got := retryable.DedupPatterns([]string{"a", "b", "a", "c", "b"}); fmt.Sprint(got) != "[a b c]" {
+		t.Errorf("Expected [a b c], got %v", got)
+	}
+}
+
+// TestClassifyAll tests that a corpus of errors is audited for gaps and conflicts between the lists.
+func TestClassifyAll(t *testing.T) {
+	timeout := errors.New("i/o timeout")
+	refused := errors.New("connection refused")
+	denied := errors.New("permission denied")
+	deniedTimeout := errors.New("permission denied: token refresh timeout")
+	unknown := errors.New("unexpected end of JSON input")
+
+	classes := retryable.ClassifyAll([]error{timeout, refused, denied, deniedTimeout, unknown},
+		[]string{"timeout", "refused"}, []string{"permission denied"})
+
+	expected := map[error]string{
+		timeout:       "retryable",
+		refused:       "retryable",
+		denied:        "non-retryable",
+		deniedTimeout: "conflict",
+		unknown:       "unmatched",
+	}
+	if len(classes) != len(expected) {
+		t.Fatalf("Expected %d classified errors, got %v", len(expected), classes)
+	}
+	for err, class := range expected {
+		if classes[err] != class {
+			t.Errorf("Expected %q to be classified %s, got %s", err, class, classes[err])
+		}
+	}
+}
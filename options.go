@@ -0,0 +1,479 @@
+package retryable
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"sync/atomic"
+	"time"
+)
+
+// unlimitedAttempts is the attempt limit of the functions bounded by time rather than by attempts.
+const unlimitedAttempts = math.MaxInt
+
+// Option configures the retry loop of RetryWithOptions and the other option-based functions.
+type Option func(*config)
+
+// config holds the settings assembled from a list of options.
+type config struct {
+	maxAttempts         int
+	backoff             BackoffStrategy
+	jitter              float64
+	monotonicJitter     bool
+	maxGrowthFactor     float64
+	firstRetryImmediate bool
+	delayModifier       func(base time.Duration) time.Duration
+	historyDelay        func(history []AttemptRecord) time.Duration
+	delayRounding       time.Duration
+	rand                *rand.Rand
+	budget              time.Duration
+	truncateDelay       bool
+	attemptTimeout      func() time.Duration
+	finalAttempt        func(ctx context.Context) context.Context
+	latencies           *latencyTracker
+	clock               Clock
+	quiet               bool
+	logStart            bool
+	giveUpSummary       bool
+	isRetryable         func(error) bool
+	acceptResult        func(value any) bool
+	earlyExit           func() (value any, ok bool)
+	healthy             func() bool
+	retryEOF            bool
+	beforeAttempt       func(attempt int)
+	afterAttempt        func(attempt int, err error, duration time.Duration)
+	onFirstRetry        func(err error)
+	onDelayAdjusted     func(requested, actual time.Duration, reason string)
+	onRetryEvent        func(event RetryEvent)
+	recordCategory      func(err error)
+	cleanup             func()
+	finalCleanup        bool
+
+	returnFirstError bool
+	aggregateErrors  bool
+	maxErrors        int
+	liveAttempts     *atomic.Int64
+	tracer           Tracer
+
+	sameProgress func(prev, cur any) bool
+	maxStalls    int
+
+	shouldContinue func(state RetryState) bool
+	categorize     func(error) string
+
+	statefulBackoff     bool
+	streak              *failureStreak
+	streakDecay         time.Duration
+	resetAfterSuccesses int
+	shutdown            <-chan struct{}
+	sem                 chan struct{}
+	breaker             *CircuitBreaker
+	store               BackoffStore
+	storeKey            string
+
+	retryBody      func(body []byte) bool
+	retryBodyLimit int64
+
+	problems []error
+}
+
+// newConfig applies the options on top of the package defaults.
+func newConfig(opts []Option) *config {
+	c := &config{
+		maxAttempts: DefaultMaxAttempts,
+		backoff:     DefaultBackoff(),
+		clock:       realClock{},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithMaxAttempts sets the maximum number of attempts, overriding DefaultMaxAttempts. A value below 1
+// counts as 1, though ValidateConfig and strict mode report it.
+func WithMaxAttempts(maxAttempts int) Option {
+	return func(c *config) {
+		c.maxAttempts = maxAttempts
+	}
+}
+
+// WithDelay sets a constant time to wait between attempts, overriding DefaultBackoff.
+// It is equivalent to WithConstantBackoff.
+func WithDelay(delay time.Duration) Option {
+	return WithConstantBackoff(delay)
+}
+
+// WithClock sets the Clock used to read the current time and to wait between attempts.
+// It is meant for tests; the default clock is backed by the time package.
+func WithClock(clock Clock) Option {
+	return func(c *config) {
+		c.clock = clock
+	}
+}
+
+// WithRetryIf sets a custom check deciding whether an error is retryable.
+// When it returns false the loop stops immediately with OutcomeNonRetryable.
+// By default every error is retried, except io.EOF, see WithRetryOnEOF.
+func WithRetryIf(isRetryable func(error) bool) Option {
+	return func(c *config) {
+		c.isRetryable = isRetryable
+	}
+}
+
+// WithRetryOnEOF makes errors matching io.EOF go through the usual classification. By default they stop
+// the loop immediately with OutcomeNonRetryable, before any custom check, since io.EOF means the end of
+// a stream rather than a transient condition and retrying past it is almost always a bug.
+func WithRetryOnEOF() Option {
+	return func(c *config) {
+		c.retryEOF = true
+	}
+}
+
+// WithOnFirstRetry registers a hook called with the error of the first attempt when the loop decides to
+// retry it, right before the first delay, e.g. to signal that an operation went from healthy to degraded.
+// It is called at most once per call, however many retries follow, and not at all when the first attempt
+// succeeds or is not retried.
+func WithOnFirstRetry(hook func(err error)) Option {
+	return func(c *config) {
+		c.onFirstRetry = hook
+	}
+}
+
+// WithOnDelayAdjusted registers a hook called when the loop waits other than the delay it computed, with
+// both delays and the reason: "modified", "stopped", "budget", "deadline", "canceled" or "shutdown".
+func WithOnDelayAdjusted(hook func(requested, actual time.Duration, reason string)) Option {
+	return func(c *config) {
+		c.onDelayAdjusted = hook
+	}
+}
+
+// RetryEvent describes a failed attempt once its error has been classified, see WithOnRetryEvent.
+type RetryEvent struct {
+	// Attempt is the 1-based number of the attempt.
+	Attempt int
+	// Err is the error returned by the function.
+	Err error
+	// Duration is how long the call took.
+	Duration time.Duration
+	// Classification is "retryable:" or "non-retryable:" followed by the rule that decided: "permanent",
+	// "marked", "eof", "check", "default" or "category-change".
+	Classification string
+}
+
+// WithOnRetryEvent registers a hook called after each failed attempt, the final one included, once its
+// error has been classified and before the loop stops or waits, with the RetryEvent describing it, which
+// makes it obvious why the loop did or did not retry a given error.
+func WithOnRetryEvent(hook func(event RetryEvent)) Option {
+	return func(c *config) {
+		c.onRetryEvent = hook
+	}
+}
+
+// WithCategoryMetrics passes the category of the error of each failed attempt about to be retried, as
+// returned by categorize, to record, e.g. to increment a counter labeled with it.
+func WithCategoryMetrics(categorize func(error) string, record func(category string)) Option {
+	return func(c *config) {
+		c.recordCategory = func(err error) {
+			record(categorize(err))
+		}
+	}
+}
+
+// WithCleanup registers a function called after each failed attempt that is going to be retried, before
+// the delay, to release the resources allocated by the attempt. It is never called after a success, and
+// after the final failed attempt only with WithFinalCleanup.
+func WithCleanup(cleanup func()) Option {
+	return func(c *config) {
+		c.cleanup = cleanup
+	}
+}
+
+// WithFinalCleanup makes the function registered with WithCleanup also run after the failed attempt that
+// ends the loop, whatever the reason the loop gives up.
+func WithFinalCleanup() Option {
+	return func(c *config) {
+		c.finalCleanup = true
+	}
+}
+
+// WithReturnFirstError makes the loop return the first error observed instead of the last one when it
+// gives up after exhausting its attempts or time budget, which is often the root cause of the failure.
+// By default the last error is returned. It does not apply to non-retryable errors and cancellations,
+// which always report the error that stopped the loop.
+func WithReturnFirstError() Option {
+	return func(c *config) {
+		c.returnFirstError = true
+	}
+}
+
+// WithAggregateErrors keeps the error of every attempt, in order, in the Errors field of the returned
+// *RetryError, in addition to the single error reported by Err.
+func WithAggregateErrors() Option {
+	return func(c *config) {
+		c.aggregateErrors = true
+	}
+}
+
+// WithMaxAggregatedErrors bounds the errors kept for the Errors fields of RetryStats and of the *RetryError
+// returned with WithAggregateErrors to the n most recent ones, older errors being dropped as new attempts
+// fail, so the memory used by a long loop such as RetryForever stays bounded. n must be at least 1;
+// by default every error is kept.
+func WithMaxAggregatedErrors(n int) Option {
+	return func(c *config) {
+		if n < 1 {
+			c.invalid("max aggregated errors must be at least 1, got %d", n)
+		}
+		c.maxErrors = n
+	}
+}
+
+// WithLogAttemptStart logs a line through the logger set with SetLoggerWriter right before each call of
+// the function, in addition to the line logged after each failure, to correlate the attempts with the
+// logs of the downstream service. Like every log of the package, it is not emitted while logging is
+// disabled.
+func WithLogAttemptStart() Option {
+	return func(c *config) {
+		c.logStart = true
+	}
+}
+
+// WithGiveUpSummary logs a single line of stable format when the loop gives up, with the attempts, the time
+// elapsed, the last error, a tally of the distinct errors and the reason, e.g.:
+//
+//	Gave up after 5 attempt(s) over 31s; last error: "conn refused"; errors seen: "timeout" x3, "conn refused" x2; reason: max_attempts
+//
+// The reason is one of max_attempts, non_retryable, context_cancelled, deadline_exceeded, budget_exhausted,
+// circuit_open, no_progress, shutdown, stopped, dependency_unhealthy and invalid_config.
+func WithGiveUpSummary() Option {
+	return func(c *config) {
+		c.giveUpSummary = true
+	}
+}
+
+// WithBeforeAttempt registers a hook called right before each invocation of the function,
+// with the 1-based attempt number. It runs after the delay that precedes the attempt, if any.
+func WithBeforeAttempt(hook func(attempt int)) Option {
+	return func(c *config) {
+		c.beforeAttempt = hook
+	}
+}
+
+// WithAfterAttempt registers a hook called right after each invocation of the function, the final one
+// included, with the attempt number, its error and its duration, before the error is classified.
+func WithAfterAttempt(hook func(attempt int, err error, duration time.Duration)) Option {
+	return func(c *config) {
+		c.afterAttempt = hook
+	}
+}
+
+// WithLiveAttemptCounter increments the given counter each time an attempt starts, so a monitoring
+// goroutine can read the attempt count of a long-running loop at any time, e.g. with RetryForever.
+// The counter belongs to the caller: the loop never resets or reads it, it only increments it, so the
+// same counter can be shared by several loops to count their attempts together.
+func WithLiveAttemptCounter(counter *atomic.Int64) Option {
+	return func(c *config) {
+		c.liveAttempts = counter
+	}
+}
+
+// WithNoProgressDetection aborts the loop when the result of a failed attempt is equal to the result of
+// the previous one, according to equal, for maxStalls consecutive attempts, which suggests the operation
+// is stuck. The returned *RetryError then matches ErrNoProgress and wraps the last error.
+// Its type parameter must match the result type of the function; otherwise the detection never triggers.
+func WithNoProgressDetection[T any](equal func(prev, cur T) bool, maxStalls int) Option {
+	return func(c *config) {
+		if maxStalls < 1 {
+			c.invalid("max stalls must be at least 1, got %d", maxStalls)
+		}
+		c.maxStalls = maxStalls
+		c.sameProgress = func(prev, cur any) bool {
+			p, okPrev := prev.(T)
+			v, okCur := cur.(T)
+			return okPrev && okCur && equal(p, v)
+		}
+	}
+}
+
+// WithAcceptResult makes the loop treat a failed attempt as a success, returning its result with a nil
+// error, when accept returns true for the result. Its type parameter must match that of the function.
+func WithAcceptResult[T any](accept func(value T) bool) Option {
+	return func(c *config) {
+		c.acceptResult = func(value any) bool {
+			v, ok := value.(T)
+			return ok && accept(v)
+		}
+	}
+}
+
+// WithEarlyExit makes the loop call check before each attempt and return the value it reports as a success,
+// without calling the function, once it returns true. check must be safe for concurrent use, and its type
+// parameter must match that of the function.
+func WithEarlyExit[T any](check func() (T, bool)) Option {
+	return func(c *config) {
+		c.earlyExit = func() (any, bool) {
+			return check()
+		}
+	}
+}
+
+// WithAbortWhen makes the loop call healthy before each attempt and stop with OutcomeUnhealthy, matching
+// ErrDependencyUnhealthy, once it returns false. healthy must not block.
+func WithAbortWhen(healthy func() bool) Option {
+	return func(c *config) {
+		c.healthy = healthy
+	}
+}
+
+// WithStopOnErrorCategoryChange stops the loop with OutcomeNonRetryable when the category of an error, as
+// returned by categorize, differs from that of the error of the previous attempt.
+func WithStopOnErrorCategoryChange(categorize func(error) string) Option {
+	return func(c *config) {
+		c.categorize = categorize
+	}
+}
+
+// RetryState describes a loop about to retry, for the function set with WithContinueFunc.
+type RetryState struct {
+	// Attempt is the number of attempts made so far.
+	Attempt int
+	// Elapsed is the time spent in the loop so far, attempts and delays included.
+	Elapsed time.Duration
+	// LastError is the error of the last attempt.
+	LastError error
+	// TotalDelay is the sum of the delays waited so far.
+	TotalDelay time.Duration
+	// NextDelay is the delay the loop is about to wait before the next attempt.
+	NextDelay time.Duration
+}
+
+// WithContinueFunc makes the loop call shouldContinue before each delay and stop with OutcomeStopped,
+// matching ErrStoppedByPredicate, if it returns false. The built-in limits still apply.
+func WithContinueFunc(shouldContinue func(state RetryState) bool) Option {
+	return func(c *config) {
+		c.shouldContinue = shouldContinue
+	}
+}
+
+// RetryWithOptions executes the provided function until it succeeds or the maximum number of attempts is
+// reached, configured with options on top of DefaultMaxAttempts and DefaultBackoff. On failure the error is
+// a *RetryError wrapping the last error.
+func RetryWithOptions[T any](fn func() (T, error), opts ...Option) (T, error) {
+	res := retryLoop(DefaultContext(), ignoreContext(fn), newConfig(opts))
+	return res.Value, res.Err
+}
+
+// MustOrPanic executes the provided function like RetryWithOptions and returns its result, or panics with
+// the *RetryError, whose message includes the outcome and the attempt count, if it ultimately fails.
+// It is meant for program initialization, such as init functions or startup code that cannot proceed
+// without the resource anyway; a recover handler can inspect the panic value with a type assertion.
+func MustOrPanic[T any](fn func() (T, error), opts ...Option) T {
+	value, err := RetryWithOptions(fn, opts...)
+	if err != nil {
+		panic(err)
+	}
+	return value
+}
+
+// RetryWithContext is like RetryWithOptions but passes the context to the function and stops once it is
+// done, or when the next attempt could not end before its deadline. The error then also wraps the context
+// error.
+func RetryWithContext[T any](ctx context.Context, fn func(context.Context) (T, error), opts ...Option) (T, error) {
+	res := retryLoop(ctx, fn, newConfig(opts))
+	return res.Value, res.Err
+}
+
+// RetryWithResult is like RetryWithContext but returns the detailed RetryResult, including the number
+// of attempts and the Outcome explaining why the loop terminated.
+func RetryWithResult[T any](ctx context.Context, fn func(context.Context) (T, error), opts ...Option) RetryResult[T] {
+	return retryLoop(ctx, fn, newConfig(opts))
+}
+
+// RetryWithContextAndStats is like RetryWithContext but also returns the RetryStats of the loop: the number
+// of attempts, the total elapsed time, the error of every failed attempt and the outcome. It honors every
+// option, and the stats are filled on every termination path, success included.
+func RetryWithContextAndStats[T any](ctx context.Context, fn func(context.Context) (T, error), opts ...Option) (T, RetryStats, error) {
+	res := retryLoop(ctx, fn, newConfig(opts))
+	return res.Value, res.RetryStats, res.Err
+}
+
+// RetryWithContextErrors is like RetryWithContext but, when the context stops the loop, returns the context
+// error as ctxErr and the last error of the function, nil if none, as err.
+func RetryWithContextErrors[T any](ctx context.Context, fn func(context.Context) (T, error), opts ...Option) (value T, err, ctxErr error) {
+	res := retryLoop(ctx, fn, newConfig(opts))
+	if res.ctxErr == nil {
+		return res.Value, res.Err, nil
+	}
+	if n := len(res.Errors); n > 0 {
+		err = res.Errors[n-1]
+	}
+	return res.Value, err, res.ctxErr
+}
+
+// RetryWithDeadline executes the provided function, with unlimited attempts unless WithMaxAttempts is
+// given, until it succeeds or the next delay would end past the time budget, measured from the first
+// attempt, returning a *RetryError with OutcomeDeadlineExceeded in that case.
+func RetryWithDeadline[T any](fn func() (T, error), budget time.Duration, opts ...Option) (T, error) {
+	c := newConfig(append([]Option{WithMaxAttempts(unlimitedAttempts)}, opts...))
+	c.budget = budget
+	res := retryLoop(DefaultContext(), ignoreContext(fn), c)
+	return res.Value, res.Err
+}
+
+// RetryFitAttempts executes the provided function up to minAttempts times with exponential delays, growing
+// by multiplier, scaled so that the attempts fit within deadline as RetryWithDeadline enforces it. It
+// returns an error wrapping ErrInvalidConfig unless minAttempts and multiplier are at least 1.
+func RetryFitAttempts[T any](fn func() (T, error), minAttempts int, deadline time.Duration, multiplier float64, opts ...Option) (T, error) {
+	if minAttempts < 1 || multiplier < 1 {
+		var zero T
+		err := invalidConfig("fitting needs at least 1 attempt and a multiplier of at least 1, got %d and %v", minAttempts, multiplier)
+		return zero, &RetryError{Outcome: OutcomeInvalidConfig, Err: err}
+	}
+	c := newConfig(append(append([]Option(nil), opts...), WithMaxAttempts(minAttempts)))
+	c.budget = deadline
+	start := c.clock.Now()
+	c.historyDelay = func(history []AttemptRecord) time.Duration {
+		var slowest time.Duration
+		for _, record := range history {
+			slowest = max(slowest, record.Duration)
+		}
+		left := minAttempts - len(history)
+		available := deadline - c.clock.Now().Sub(start) - time.Duration(left)*slowest
+		return time.Duration(float64(available) / geometricSum(left, multiplier))
+	}
+	res := retryLoop(DefaultContext(), ignoreContext(fn), c)
+	return res.Value, res.Err
+}
+
+// geometricSum returns 1 + multiplier + ... + multiplier^(n-1), the sum of n delays growing by multiplier
+// relative to the first one.
+func geometricSum(n int, multiplier float64) float64 {
+	if multiplier == 1 {
+		return float64(n)
+	}
+	return (math.Pow(multiplier, float64(n)) - 1) / (multiplier - 1)
+}
+
+// RetryForever executes the provided function until it succeeds or the context is done, with no limit on
+// the number of attempts unless WithMaxAttempts is given. On cancellation the returned *RetryError wraps
+// both the context error and the last error of the function.
+func RetryForever[T any](ctx context.Context, fn func(context.Context) (T, error), opts ...Option) (T, error) {
+	res := retryLoop(ctx, fn, newConfig(append([]Option{WithMaxAttempts(unlimitedAttempts)}, opts...)))
+	return res.Value, res.Err
+}
+
+// RetryFor is like RetryWithDeadline with a constant delay and a context, except that a delay ending past
+// the duration is truncated so that a last attempt is made at the end.
+func RetryFor[T any](ctx context.Context, fn func(context.Context) (T, error), delay, duration time.Duration, opts ...Option) (T, error) {
+	c := newConfig(append([]Option{WithMaxAttempts(unlimitedAttempts), WithDelay(delay)}, opts...))
+	c.budget = duration
+	c.truncateDelay = true
+	res := retryLoop(ctx, fn, c)
+	return res.Value, res.Err
+}
+
+// RetryAlwaysWith is the context-aware counterpart of Retry, retrying every error, io.EOF included, except
+// those marked with Permanent, with the backoff strategy.
+func RetryAlwaysWith[T any](ctx context.Context, fn func(context.Context) (T, error), maxAttempts int, strategy BackoffStrategy) (T, error) {
+	return RetryWithContext(ctx, fn, WithMaxAttempts(maxAttempts), WithBackoff(strategy), WithRetryOnEOF())
+}
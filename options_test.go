@@ -0,0 +1,835 @@
+package retryable_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestRetryWithOptions tests that the options override the default attempts and delay.
+func TestRetryWithOptions(t *testing.T) {
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		return 0, errors.New("error")
+	}
+
+	_, err := retryable.RetryWithOptions(fn, retryable.WithMaxAttempts(5), retryable.WithDelay(1*time.Millisecond))
+	if err == nil || attempts != 5 {
+		t.Errorf("Expected 5 failed attempts, got %d attempts with error %v", attempts, err)
+	}
+}
+
+// TestBeforeAndAfterAttemptHooks tests the ordering of the attempt hooks around each call.
+func TestBeforeAndAfterAttemptHooks(t *testing.T) {
+	var events []string
+	attempts := 0
+	fn := func() (bool, error) {
+		attempts++
+		events = append(events, fmt.Sprintf("call %d", attempts))
+		if attempts < 2 {
+			return false, errors.New("temporary error")
+		}
+		return true, nil
+	}
+
+	result, err := retryable.RetryWithOptions(fn,
+		retryable.WithMaxAttempts(3),
+		retryable.WithDelay(1*time.Millisecond),
+		retryable.WithBeforeAttempt(func(attempt int) {
+			events = append(events, fmt.Sprintf("before %d", attempt))
+		}),
+		retryable.WithAfterAttempt(func(attempt int, err error, duration time.Duration) {
+			events = append(events, fmt.Sprintf("after %d: %v", attempt, err))
+		}),
+	)
+	if err != nil || !result {
+		t.Fatalf("Expected success on the second attempt, got %v with error %v", result, err)
+	}
+
+	expected := "before 1,call 1,after 1: temporary error,before 2,call 2,after 2: <nil>"
+	if got := strings.Join(events, ","); got != expected {
+		t.Errorf("Expected events %q, got %q", expected, got)
+	}
+}
+
+// TestWithReturnFirstError tests which end of the error sequence is returned on exhaustion.
+func TestWithReturnFirstError(t *testing.T) {
+	newFn := func() func() (int, error) {
+		attempts := 0
+		return func() (int, error) {
+			attempts++
+			return 0, fmt.Errorf("error %d", attempts)
+		}
+	}
+
+	_, err := retryable.RetryWithOptions(newFn(), retryable.WithMaxAttempts(3), retryable.WithDelay(1*time.Millisecond))
+	var retryErr *retryable.RetryError
+	if !errors.As(err, &retryErr) || retryErr.Err.Error() != "error 3" {
+		t.Errorf("Expected the last error by default, got %v", err)
+	}
+
+	_, err = retryable.RetryWithOptions(newFn(),
+		retryable.WithMaxAttempts(3),
+		retryable.WithDelay(1*time.Millisecond),
+		retryable.WithReturnFirstError(),
+	)
+	if !errors.As(err, &retryErr) || retryErr.Err.Error() != "error 1" {
+		t.Errorf("Expected the first error with WithReturnFirstError, got %v", err)
+	}
+}
+
+// TestWithAggregateErrors tests that every attempt error is kept in order alongside the reported one.
+func TestWithAggregateErrors(t *testing.T) {
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		return 0, fmt.Errorf("error %d", attempts)
+	}
+
+	_, err := retryable.RetryWithOptions(fn,
+		retryable.WithMaxAttempts(3),
+		retryable.WithDelay(1*time.Millisecond),
+		retryable.WithReturnFirstError(),
+		retryable.WithAggregateErrors(),
+	)
+
+	var retryErr *retryable.RetryError
+	if !errors.As(err, &retryErr) || len(retryErr.Errors) != 3 {
+		t.Fatalf("Expected 3 aggregated errors, got %v", err)
+	}
+	if retryErr.Errors[0].Error() != "error 1" || retryErr.Errors[2].Error() != "error 3" || retryErr.Err.Error() != "error 1" {
+		t.Errorf("Unexpected aggregated errors %v with reported error %v", retryErr.Errors, retryErr.Err)
+	}
+}
+
+// TestWithMaxAggregatedErrors tests that only the most recent errors are kept over many attempts.
+func TestWithMaxAggregatedErrors(t *testing.T) {
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		return 0, fmt.Errorf("error %d", attempts)
+	}
+
+	_, err := retryable.RetryWithOptions(fn,
+		retryable.WithMaxAttempts(1000),
+		retryable.WithDelay(0),
+		retryable.WithAggregateErrors(),
+		retryable.WithMaxAggregatedErrors(5),
+	)
+
+	var retryErr *retryable.RetryError
+	if !errors.As(err, &retryErr) || len(retryErr.Errors) != 5 {
+		t.Fatalf("Expected 5 aggregated errors, got %v", err)
+	}
+	for i, e := range retryErr.Errors {
+		if want := fmt.Sprintf("error %d", 996+i); e.Error() != want {
+			t.Errorf("Expected aggregated error %d to be %q, got %q", i, want, e)
+		}
+	}
+	if retryErr.Attempts != 1000 || retryErr.Err.Error() != "error 1000" {
+		t.Errorf("Expected 1000 attempts ending with the last error, got %d and %v", retryErr.Attempts, retryErr.Err)
+	}
+}
+
+// TestWithAcceptResult tests that an acceptable result ends the loop with a nil error, whatever the classification.
+func TestWithAcceptResult(t *testing.T) {
+	attempts := 0
+	fn := func() (string, error) {
+		attempts++
+		if attempts < 2 {
+			return "", errors.New("empty")
+		}
+		return "partial", errors.New("incomplete")
+	}
+
+	value, err := retryable.RetryWithOptions(fn,
+		retryable.WithMaxAttempts(5),
+		retryable.WithDelay(1*time.Millisecond),
+		retryable.WithAcceptResult(func(v string) bool { return v != "" }),
+		retryable.WithRetryIf(func(err error) bool { return err.Error() != "incomplete" }),
+	)
+
+	if err != nil || value != "partial" || attempts != 2 {
+		t.Errorf("Expected the partial result on attempt 2 with no error, got %q, %v after %d attempts", value, err, attempts)
+	}
+}
+
+// TestWithLogAttemptStart tests that the start of every attempt is logged before the failure lines, unless logging is disabled.
+func TestWithLogAttemptStart(t *testing.T) {
+	var lines []string
+	retryable.SetLoggerWriter(func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	})
+	defer retryable.SetLoggerWriter(log.Printf)
+
+	fn := func() (int, error) {
+		lines = append(lines, "call")
+		return 0, errors.New("boom")
+	}
+	retryable.RetryWithOptions(fn, retryable.WithMaxAttempts(2), retryable.WithDelay(0), retryable.WithLogAttemptStart())
+
+	expected := "Starting attempt 1/2...|call|Attempt 1/2 failed with an error: boom. Retrying in 0s...|Starting attempt 2/2...|call"
+	if got := strings.Join(lines, "|"); got != expected {
+		t.Errorf("Expected log timeline %q, got %q", expected, got)
+	}
+
+	lines = nil
+	retryable.DisableLogging()
+	defer retryable.EnableLogging()
+	retryable.RetryWithOptions(fn, retryable.WithMaxAttempts(2), retryable.WithDelay(0), retryable.WithLogAttemptStart())
+	if got := strings.Join(lines, "|"); got != "call|call" {
+		t.Errorf("Expected no log line while logging is disabled, got %q", got)
+	}
+}
+
+// TestRetryWithOptionsEOF tests that io.EOF is not retried by default, unless WithRetryOnEOF is given.
+func TestRetryWithOptionsEOF(t *testing.T) {
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		return 0, fmt.Errorf("read header: %w", io.EOF)
+	}
+
+	_, err := retryable.RetryWithOptions(fn, retryable.WithMaxAttempts(3), retryable.WithDelay(1*time.Millisecond))
+	if attempts != 1 || !errors.Is(err, retryable.ErrNonRetryable) || !errors.Is(err, io.EOF) {
+		t.Errorf("Expected a single non-retryable attempt, got %d attempts and %v", attempts, err)
+	}
+
+	attempts = 0
+	_, err = retryable.RetryWithOptions(fn, retryable.WithMaxAttempts(3), retryable.WithDelay(1*time.Millisecond), retryable.WithRetryOnEOF())
+	if attempts != 3 || !errors.Is(err, retryable.ErrMaxAttemptsReached) {
+		t.Errorf("Expected 3 attempts with WithRetryOnEOF, got %d attempts and %v", attempts, err)
+	}
+}
+
+// TestRetryForever tests that RetryForever keeps retrying past the default attempts until success.
+func TestRetryForever(t *testing.T) {
+	attempts := 0
+	fn := func(context.Context) (int, error) {
+		attempts++
+		if attempts < retryable.DefaultMaxAttempts+5 {
+			return 0, errors.New("not yet")
+		}
+		return attempts, nil
+	}
+
+	result, err := retryable.RetryForever(context.Background(), fn, retryable.WithDelay(1*time.Millisecond))
+	if err != nil || result != retryable.DefaultMaxAttempts+5 {
+		t.Errorf("Expected success after %d attempts, got %v with error %v", retryable.DefaultMaxAttempts+5, result, err)
+	}
+}
+
+// TestWithLiveAttemptCounter tests that the caller's counter is incremented live by a running loop.
+func TestWithLiveAttemptCounter(t *testing.T) {
+	var counter atomic.Int64
+	counter.Store(10)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	fn := func(context.Context) (int, error) {
+		if counter.Load() == 15 {
+			cancel()
+		}
+		return 0, errors.New("stuck")
+	}
+
+	_, err := retryable.RetryForever(ctx, fn, retryable.WithDelay(1*time.Millisecond), retryable.WithLiveAttemptCounter(&counter))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("Expected the loop to be canceled, got %v", err)
+	}
+	if got := counter.Load(); got != 15 {
+		t.Errorf("Expected the counter to be incremented from 10 to 15, got %d", got)
+	}
+}
+
+// TestWithNoProgressDetection tests that an unchanged result across attempts aborts the loop.
+func TestWithNoProgressDetection(t *testing.T) {
+	progress := []int{1, 2, 2, 2, 2, 2}
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		return progress[attempts-1], errors.New("not done")
+	}
+
+	_, err := retryable.RetryWithOptions(fn,
+		retryable.WithMaxAttempts(len(progress)),
+		retryable.WithDelay(1*time.Millisecond),
+		retryable.WithNoProgressDetection(func(prev, cur int) bool { return prev == cur }, 2),
+	)
+	if !errors.Is(err, retryable.ErrNoProgress) || err.(*retryable.RetryError).Err.Error() != "not done" {
+		t.Errorf("Expected ErrNoProgress wrapping the last error, got %v", err)
+	}
+	if attempts != 4 {
+		t.Errorf("Expected to abort after 2 stalled attempts, got %d attempts", attempts)
+	}
+}
+
+// TestWithNoProgressDetectionProgressing tests that a changing result never triggers the detection.
+func TestWithNoProgressDetectionProgressing(t *testing.T) {
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		return attempts, errors.New("not done")
+	}
+
+	_, err := retryable.RetryWithOptions(fn,
+		retryable.WithMaxAttempts(5),
+		retryable.WithDelay(1*time.Millisecond),
+		retryable.WithNoProgressDetection(func(prev, cur int) bool { return prev == cur }, 1),
+	)
+	if errors.Is(err, retryable.ErrNoProgress) || attempts != 5 {
+		t.Errorf("Expected max attempts without ErrNoProgress, got %v after %d attempts", err, attempts)
+	}
+}
+
+// TestWithOnFirstRetry tests that the hook fires exactly once, with the first error, across many retries.
+func TestWithOnFirstRetry(t *testing.T) {
+	var calls []error
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		return 0, fmt.Errorf("error %d", attempts)
+	}
+
+	retryable.RetryWithOptions(fn,
+		retryable.WithMaxAttempts(5),
+		retryable.WithDelay(1*time.Millisecond),
+		retryable.WithOnFirstRetry(func(err error) { calls = append(calls, err) }),
+	)
+	if len(calls) != 1 || calls[0].Error() != "error 1" {
+		t.Errorf("Expected a single call with the first error, got %v", calls)
+	}
+}
+
+// TestWithOnFirstRetryNotCalled tests that the hook does not fire when no retry happens.
+func TestWithOnFirstRetryNotCalled(t *testing.T) {
+	called := false
+	fn := func() (bool, error) { return true, nil }
+
+	retryable.RetryWithOptions(fn, retryable.WithOnFirstRetry(func(error) { called = true }))
+	if called {
+		t.Errorf("Expected the hook to not fire without retries")
+	}
+}
+
+// TestMustOrPanic tests that MustOrPanic returns the result on success.
+func TestMustOrPanic(t *testing.T) {
+	attempts := 0
+	value := retryable.MustOrPanic(func() (string, error) {
+		attempts++
+		if attempts < 2 {
+			return "", errors.New("not ready")
+		}
+		return "ready", nil
+	}, retryable.WithDelay(1*time.Millisecond))
+
+	if value != "ready" {
+		t.Errorf("Expected ready, got %q", value)
+	}
+}
+
+// TestMustOrPanicFailure tests that MustOrPanic panics with a *RetryError including the attempt count.
+func TestMustOrPanicFailure(t *testing.T) {
+	defer func() {
+		retryErr, ok := recover().(*retryable.RetryError)
+		if !ok {
+			t.Fatalf("Expected to panic with a *RetryError")
+		}
+		if retryErr.Attempts != 2 || !strings.Contains(retryErr.Error(), "2 attempt") || retryErr.Err.Error() != "unreachable" {
+			t.Errorf("Unexpected panic value %v", retryErr)
+		}
+	}()
+
+	retryable.MustOrPanic(func() (int, error) {
+		return 0, errors.New("unreachable")
+	}, retryable.WithMaxAttempts(2), retryable.WithDelay(1*time.Millisecond))
+	t.Errorf("Expected MustOrPanic to panic")
+}
+
+// TestWithCleanup tests that the cleanup skips the final attempt unless WithFinalCleanup is set.
+func TestWithCleanup(t *testing.T) {
+	fn := func() (int, error) { return 0, errors.New("lock busy") }
+
+	cleanups := 0
+	retryable.RetryWithOptions(fn,
+		retryable.WithMaxAttempts(3),
+		retryable.WithDelay(1*time.Millisecond),
+		retryable.WithCleanup(func() { cleanups++ }),
+	)
+	if cleanups != 2 {
+		t.Errorf("Expected 2 cleanups for 3 attempts, got %d", cleanups)
+	}
+
+	cleanups = 0
+	retryable.RetryWithOptions(fn,
+		retryable.WithMaxAttempts(3),
+		retryable.WithDelay(1*time.Millisecond),
+		retryable.WithCleanup(func() { cleanups++ }),
+		retryable.WithFinalCleanup(),
+	)
+	if cleanups != 3 {
+		t.Errorf("Expected 3 cleanups with WithFinalCleanup, got %d", cleanups)
+	}
+}
+
+// TestWithFinalCleanupNonRetryable tests that the final cleanup also runs when a non-retryable error stops the loop, but never after a success.
+func TestWithFinalCleanupNonRetryable(t *testing.T) {
+	cleanups := 0
+	retryable.RetryWithOptions(func() (int, error) { return 0, errors.New("fatal") },
+		retryable.WithRetryIf(func(error) bool { return false }),
+		retryable.WithCleanup(func() { cleanups++ }),
+		retryable.WithFinalCleanup(),
+	)
+	if cleanups != 1 {
+		t.Errorf("Expected 1 cleanup after the non-retryable error, got %d", cleanups)
+	}
+
+	cleanups = 0
+	retryable.RetryWithOptions(func() (int, error) { return 1, nil },
+		retryable.WithCleanup(func() { cleanups++ }),
+		retryable.WithFinalCleanup(),
+	)
+	if cleanups != 0 {
+		t.Errorf("Expected no cleanup after a success, got %d", cleanups)
+	}
+}
+
+// TestRetryAlwaysWith tests that every error is retried following the backoff schedule.
+func TestRetryAlwaysWith(t *testing.T) {
+	delays := recordDelays(t)
+	attempts := 0
+	fn := func(context.Context) (int, error) {
+		attempts++
+		if attempts < 4 {
+			return 0, io.EOF
+		}
+		return attempts, nil
+	}
+
+	ctx := context.Background()
+	value, err := retryable.RetryAlwaysWith(ctx, fn, 5, retryable.ExponentialBackoff(1*time.Millisecond, 2, 0))
+	if err != nil || value != 4 {
+		t.Errorf("Expected success on the fourth attempt, got %d with error %v", value, err)
+	}
+	expected := []time.Duration{1 * time.Millisecond, 2 * time.Millisecond, 4 * time.Millisecond}
+	if fmt.Sprint(*delays) != fmt.Sprint(expected) {
+		t.Errorf("Expected delays %v, got %v", expected, *delays)
+	}
+}
+
+// TestRetryAlwaysWithCanceled tests that canceling the context interrupts a backoff delay.
+func TestRetryAlwaysWithCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	attempts := 0
+	fn := func(context.Context) (int, error) {
+		attempts++
+		time.AfterFunc(10*time.Millisecond, cancel)
+		return 0, errors.New("down")
+	}
+
+	start := time.Now()
+	_, err := retryable.RetryAlwaysWith(ctx, fn, 5, retryable.ConstantBackoff(1*time.Hour))
+	if !errors.Is(err, context.Canceled) || attempts != 1 || time.Since(start) > 1*time.Second {
+		t.Errorf("Expected a prompt cancellation after 1 attempt, got %v after %d attempts", err, attempts)
+	}
+}
+
+// TestWithGiveUpSummary tests the single summary line logged when the loop gives up.
+func TestWithGiveUpSummary(t *testing.T) {
+	var lines []string
+	retryable.SetLoggerWriter(func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	})
+	defer retryable.SetLoggerWriter(log.Printf)
+
+	errs := []string{"timeout", "conn refused", "timeout", "timeout", "conn refused"}
+	attempts := 0
+	retryable.RetryWithOptions(func() (int, error) {
+		attempts++
+		return 0, errors.New(errs[attempts-1])
+	},
+		retryable.WithMaxAttempts(len(errs)),
+		retryable.WithDelay(1*time.Second),
+		retryable.WithClock(newFakeClock()),
+		retryable.WithMaxAggregatedErrors(1),
+		retryable.WithGiveUpSummary(),
+	)
+
+	expected := `Gave up after 5 attempt(s) over 4s; last error: "conn refused"; errors seen: "timeout" x3, "conn refused" x2; reason: max_attempts`
+	if len(lines) != 5 || lines[4] != expected {
+		t.Errorf("Expected the summary %q after 4 retry lines, got %q", expected, lines)
+	}
+
+	lines = nil
+	retryable.RetryWithOptions(func() (int, error) { return 1, nil }, retryable.WithGiveUpSummary())
+	if len(lines) != 0 {
+		t.Errorf("Expected no summary on success, got %q", lines)
+	}
+}
+
+// TestWithContinueFunc tests that a bespoke condition stops the loop, with the state it is given.
+func TestWithContinueFunc(t *testing.T) {
+	var states []retryable.RetryState
+	res := retryable.RetryWithResult(context.Background(), func(context.Context) (int, error) {
+		return 0, errors.New("down")
+	},
+		retryable.WithMaxAttempts(10),
+		retryable.WithExponentialBackoff(1*time.Second, 2, 0),
+		retryable.WithClock(newFakeClock()),
+		retryable.WithContinueFunc(func(state retryable.RetryState) bool {
+			states = append(states, state)
+			return state.TotalDelay+state.NextDelay <= 3*time.Second
+		}),
+	)
+
+	if res.Outcome != retryable.OutcomeStopped || res.Attempts != 3 || res.Err.Error() != "stopped after 3 attempt(s): down" {
+		t.Errorf("Expected the loop to stop after 3 attempts, got %v", res.Err)
+	}
+	if !errors.Is(res.Err, retryable.ErrStoppedByPredicate) || errors.Is(res.Err, retryable.ErrMaxAttemptsReached) {
+		t.Errorf("Expected the error to match only ErrStoppedByPredicate, got %v", res.Err)
+	}
+	var retryErr *retryable.RetryError
+	if !errors.As(res.Err, &retryErr) || retryErr.Unwrap().Error() != "down" {
+		t.Errorf("Expected the last error to be recoverable, got %v", res.Err)
+	}
+	last := states[len(states)-1]
+	if len(states) != 3 || last.Attempt != 3 || last.TotalDelay != 3*time.Second || last.NextDelay != 4*time.Second || last.Elapsed != 3*time.Second {
+		t.Errorf("Unexpected states %+v", states)
+	}
+}
+
+// TestWithStopOnErrorCategoryChange tests that the loop stops with the latest error when the failure mode shifts.
+func TestWithStopOnErrorCategoryChange(t *testing.T) {
+	errs := []string{"429 rate limited", "429 rate limited", "401 auth failed", "429 rate limited"}
+	attempts := 0
+	_, err := retryable.RetryWithOptions(func() (int, error) {
+		attempts++
+		return 0, errors.New(errs[attempts-1])
+	},
+		retryable.WithMaxAttempts(len(errs)),
+		retryable.WithDelay(1*time.Millisecond),
+		retryable.WithStopOnErrorCategoryChange(func(err error) string { return err.Error()[:3] }),
+	)
+
+	if attempts != 3 || !errors.Is(err, retryable.ErrNonRetryable) || !strings.Contains(err.Error(), "401 auth failed") {
+		t.Errorf("Expected the loop to stop at the auth failure, got %v after %d attempts", err, attempts)
+	}
+}
+
+// TestRetryWithContextErrors tests that the context error is returned apart from the error of the
+// function, whether the loop is canceled during a delay or during an attempt.
+func TestRetryWithContextErrors(t *testing.T) {
+	errDown := errors.New("down")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	_, err, ctxErr := retryable.RetryWithContextErrors(ctx, func(context.Context) (int, error) {
+		return 0, errDown
+	}, retryable.WithDelay(1*time.Hour), retryable.WithAfterAttempt(func(int, error, time.Duration) {
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			cancel()
+		}()
+	}))
+	if err != errDown || ctxErr != context.Canceled {
+		t.Errorf("Expected a cancellation during the delay to return both errors apart, got %v and %v", err, ctxErr)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err, ctxErr = retryable.RetryWithContextErrors(ctx, func(ctx context.Context) (int, error) {
+		<-ctx.Done()
+		return 0, fmt.Errorf("interrupted: %w", ctx.Err())
+	}, retryable.WithDelay(1*time.Millisecond))
+	if err == nil || err.Error() != "interrupted: context deadline exceeded" || ctxErr != context.DeadlineExceeded {
+		t.Errorf("Expected a cancellation during the attempt to return both errors apart, got %v and %v", err, ctxErr)
+	}
+
+	_, err, ctxErr = retryable.RetryWithContextErrors(context.Background(), func(context.Context) (int, error) {
+		return 0, errDown
+	}, retryable.WithMaxAttempts(2), retryable.WithDelay(1*time.Millisecond))
+	if !errors.Is(err, retryable.ErrMaxAttemptsReached) || ctxErr != nil {
+		t.Errorf("Expected exhaustion to return a *RetryError and no context error, got %v and %v", err, ctxErr)
+	}
+}
+
+// TestWithEarlyExit tests that the loop returns the shared value as soon as another goroutine provided it.
+func TestWithEarlyExit(t *testing.T) {
+	var shared atomic.Pointer[string]
+	attempts := 0
+	res := retryable.RetryWithResult(context.Background(), func(context.Context) (string, error) {
+		attempts++
+		// Another goroutine fills the resource while this attempt fails.
+		value := "filled elsewhere"
+		shared.Store(&value)
+		return "", errors.New("busy")
+	},
+		retryable.WithMaxAttempts(5),
+		retryable.WithDelay(1*time.Millisecond),
+		retryable.WithEarlyExit(func() (string, bool) {
+			if v := shared.Load(); v != nil {
+				return *v, true
+			}
+			return "", false
+		}),
+	)
+
+	if attempts != 1 || res.Err != nil || res.Value != "filled elsewhere" || res.Outcome != retryable.OutcomeSuccess {
+		t.Errorf("Expected an early exit with the shared value after 1 attempt, got %q, %v after %d attempts", res.Value, res.Err, attempts)
+	}
+}
+
+// TestWithAbortWhen tests that the loop stops with ErrDependencyUnhealthy once the health check fails.
+func TestWithAbortWhen(t *testing.T) {
+	var healthy atomic.Bool
+	healthy.Store(true)
+	attempts := 0
+	_, err := retryable.RetryWithOptions(func() (int, error) {
+		attempts++
+		if attempts == 2 {
+			healthy.Store(false)
+		}
+		return 0, errors.New("timeout")
+	},
+		retryable.WithMaxAttempts(5),
+		retryable.WithDelay(1*time.Millisecond),
+		retryable.WithAbortWhen(healthy.Load),
+	)
+
+	if attempts != 2 || !errors.Is(err, retryable.ErrDependencyUnhealthy) || err.Error() != "dependency unhealthy after 2 attempt(s): timeout" {
+		t.Errorf("Expected the loop to abort after 2 attempts, got %v after %d attempts", err, attempts)
+	}
+}
+
+// TestWithOnDelayAdjusted tests that the hook reports the delays the loop did not wait as computed.
+func TestWithOnDelayAdjusted(t *testing.T) {
+	var adjustments []string
+	hook := retryable.WithOnDelayAdjusted(func(requested, actual time.Duration, reason string) {
+		adjustments = append(adjustments, fmt.Sprintf("%s %v->%v", reason, requested, actual))
+	})
+
+	retryable.RetryWithDeadline(func() (int, error) { return 0, errors.New("down") }, 3*time.Second,
+		retryable.WithExponentialBackoff(1*time.Second, 2, 0),
+		retryable.WithDelayModifier(func(base time.Duration) time.Duration { return min(base, 1500*time.Millisecond) }),
+		retryable.WithClock(newFakeClock()),
+		hook,
+	)
+	expected := "[modified 2s->1.5s modified 4s->1.5s budget 1.5s->0s]"
+	if fmt.Sprint(adjustments) != expected {
+		t.Errorf("Expected adjustments %s, got %v", expected, adjustments)
+	}
+
+	adjustments = nil
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	retryable.RetryWithContext(ctx, func(context.Context) (int, error) { return 0, errors.New("down") }, retryable.WithDelay(1*time.Hour), hook)
+	if len(adjustments) != 1 || !strings.HasPrefix(adjustments[0], "canceled 1h0m0s->") {
+		t.Errorf("Expected the cancellation to be reported, got %v", adjustments)
+	}
+}
+
+// TestRetryFor tests that the loop retries for the duration, truncating the final delay to make a last attempt at the end of the window.
+func TestRetryFor(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+	var attemptTimes []time.Duration
+	_, err := retryable.RetryFor(context.Background(), func(context.Context) (int, error) {
+		attemptTimes = append(attemptTimes, clock.Now().Sub(start))
+		return 0, errors.New("database not ready")
+	}, 3*time.Second, 10*time.Second, retryable.WithClock(clock))
+
+	if !errors.Is(err, context.DeadlineExceeded) || !strings.Contains(err.Error(), "database not ready") {
+		t.Errorf("Expected the window to be used up, got %v", err)
+	}
+	expected := "[0s 3s 6s 9s 10s]"
+	if fmt.Sprint(attemptTimes) != expected {
+		t.Errorf("Expected attempts at %s, got %v", expected, attemptTimes)
+	}
+
+	attempts := 0
+	value, err := retryable.RetryFor(context.Background(), func(context.Context) (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, errors.New("database not ready")
+		}
+		return 42, nil
+	}, 3*time.Second, 10*time.Second, retryable.WithClock(clock))
+	if value != 42 || err != nil || attempts != 3 {
+		t.Errorf("Expected a success at the third attempt, got %d, %v after %d attempts", value, err, attempts)
+	}
+}
+
+// TestWithOnRetryEvent tests the classification reported for each rule.
+func TestWithOnRetryEvent(t *testing.T) {
+	errFatal := errors.New("fatal")
+	tests := []struct {
+		name     string
+		err      error
+		opts     []retryable.Option
+		expected string
+	}{
+		{"default", errors.New("down"), nil, "retryable:default"},
+		{"permanent", retryable.Permanent(errors.New("down")), nil, "non-retryable:permanent"},
+		{"marked", retryable.Retryable(errFatal), []retryable.Option{retryable.WithRetryIf(func(err error) bool { return !errors.Is(err, errFatal) })}, "retryable:marked"},
+		{"eof", io.EOF, nil, "non-retryable:eof"},
+		{"check retryable", errors.New("down"), []retryable.Option{retryable.WithRetryIf(func(error) bool { return true })}, "retryable:check"},
+		{"check non-retryable", errFatal, []retryable.Option{retryable.WithRetryIf(func(err error) bool { return !errors.Is(err, errFatal) })}, "non-retryable:check"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []retryable.RetryEvent
+			opts := append([]retryable.Option{
+				retryable.WithMaxAttempts(1),
+				retryable.WithOnRetryEvent(func(event retryable.RetryEvent) { events = append(events, event) }),
+			}, tt.opts...)
+			retryable.RetryWithOptions(func() (int, error) { return 0, tt.err }, opts...)
+			if len(events) != 1 || events[0].Attempt != 1 || events[0].Err != tt.err || events[0].Classification != tt.expected {
+				t.Errorf("Expected a single event classified %q, got %+v", tt.expected, events)
+			}
+		})
+	}
+}
+
+// TestWithOnRetryEventCategoryChange tests that a change of error category is reported as the reason to stop.
+func TestWithOnRetryEventCategoryChange(t *testing.T) {
+	errs := []string{"429 rate limited", "401 auth failed"}
+	var classifications []string
+	attempts := 0
+	retryable.RetryWithOptions(func() (int, error) {
+		attempts++
+		return 0, errors.New(errs[attempts-1])
+	},
+		retryable.WithMaxAttempts(len(errs)),
+		retryable.WithDelay(1*time.Millisecond),
+		retryable.WithStopOnErrorCategoryChange(func(err error) string { return err.Error()[:3] }),
+		retryable.WithOnRetryEvent(func(event retryable.RetryEvent) { classifications = append(classifications, event.Classification) }),
+	)
+
+	if fmt.Sprint(classifications) != "[retryable:default non-retryable:category-change]" {
+		t.Errorf("Unexpected classifications %v", classifications)
+	}
+}
+
+// TestRetryFitAttempts tests that the computed delays fit the attempts in the deadline.
+func TestRetryFitAttempts(t *testing.T) {
+	delays := recordDelays(t)
+	attempts := 0
+	_, err := retryable.RetryFitAttempts(func() (int, error) {
+		attempts++
+		return 0, errors.New("down")
+	}, 4, 70*time.Millisecond, 2, retryable.WithClock(newFakeClock()))
+
+	expected := []time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond}
+	if attempts != 4 || fmt.Sprint(*delays) != fmt.Sprint(expected) || !errors.Is(err, retryable.ErrMaxAttemptsReached) {
+		t.Errorf("Expected 4 attempts with delays %v, got %v after %d attempts: %v", expected, *delays, attempts, err)
+	}
+}
+
+// TestRetryFitAttemptsWithinDeadline tests that the time taken by the attempts is reserved, so the call
+// stays within the deadline.
+func TestRetryFitAttemptsWithinDeadline(t *testing.T) {
+	clock := newFakeClock()
+	start := clock.Now()
+	attempts := 0
+	_, err := retryable.RetryFitAttempts(func() (int, error) {
+		attempts++
+		clock.Advance(20 * time.Millisecond)
+		return 0, errors.New("down")
+	}, 3, 100*time.Millisecond, 2, retryable.WithClock(clock))
+
+	if elapsed := clock.Now().Sub(start); attempts != 3 || elapsed > 100*time.Millisecond {
+		t.Errorf("Expected 3 attempts within 100ms, got %d attempts in %v: %v", attempts, elapsed, err)
+	}
+}
+
+// TestRetryFitAttemptsInvalid tests that invalid arguments are rejected without calling the function.
+func TestRetryFitAttemptsInvalid(t *testing.T) {
+	for _, tt := range []struct {
+		attempts   int
+		multiplier float64
+	}{{0, 2}, {3, 0.5}} {
+		called := false
+		_, err := retryable.RetryFitAttempts(func() (int, error) {
+			called = true
+			return 0, nil
+		}, tt.attempts, time.Second, tt.multiplier)
+		if called || !errors.Is(err, retryable.ErrInvalidConfig) {
+			t.Errorf("Expected %d attempts with a multiplier of %v to be rejected, got %v", tt.attempts, tt.multiplier, err)
+		}
+	}
+}
+
+// TestWithGiveUpSummaryReason tests the reason logged for several terminations.
+func TestWithGiveUpSummaryReason(t *testing.T) {
+	var lines []string
+	retryable.SetLoggerWriter(func(format string, args ...interface{}) {
+		lines = append(lines, fmt.Sprintf(format, args...))
+	})
+	defer retryable.SetLoggerWriter(log.Printf)
+	fail := func(context.Context) (int, error) { return 0, errors.New("down") }
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Hour)
+	defer cancel()
+
+	tests := []struct {
+		name   string
+		run    func()
+		reason string
+	}{
+		{"non-retryable", func() {
+			retryable.RetryWithContext(context.Background(), func(context.Context) (int, error) {
+				return 0, retryable.Permanent(errors.New("denied"))
+			}, retryable.WithGiveUpSummary())
+		}, "non_retryable"},
+		{"budget", func() {
+			retryable.RetryFor(context.Background(), fail, 1*time.Second, 2*time.Second, retryable.WithClock(newFakeClock()), retryable.WithGiveUpSummary())
+		}, "budget_exhausted"},
+		{"context deadline", func() {
+			retryable.RetryWithContext(ctx, fail, retryable.WithDelay(2*time.Hour), retryable.WithGiveUpSummary())
+		}, "deadline_exceeded"},
+		{"circuit open", func() {
+			cb := retryable.NewCircuitBreaker(retryable.CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Hour})
+			retryable.RetryWithContext(context.Background(), fail, retryable.WithDelay(1*time.Millisecond), retryable.WithCircuitBreaker(cb), retryable.WithGiveUpSummary())
+		}, "circuit_open"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines = nil
+			tt.run()
+			if len(lines) == 0 || !strings.HasSuffix(lines[len(lines)-1], "; reason: "+tt.reason) {
+				t.Errorf("Expected the summary to end with reason %s, got %q", tt.reason, lines)
+			}
+		})
+	}
+}
+
+// TestWithCategoryMetrics tests that each retried error is recorded under its category, and the final one is not.
+func TestWithCategoryMetrics(t *testing.T) {
+	errs := []string{"timeout", "rate limited", "timeout", "timeout"}
+	counts := map[string]int{}
+	attempts := 0
+	retryable.RetryWithOptions(func() (int, error) {
+		attempts++
+		return 0, errors.New(errs[attempts-1])
+	},
+		retryable.WithMaxAttempts(len(errs)),
+		retryable.WithDelay(1*time.Millisecond),
+		retryable.WithCategoryMetrics(func(err error) string {
+			return strings.ReplaceAll(err.Error(), " ", "_")
+		}, func(category string) {
+			counts[category]++
+		}),
+	)
+
+	if len(counts) != 2 || counts["timeout"] != 2 || counts["rate_limited"] != 1 {
+		t.Errorf("Expected 2 timeouts and 1 rate limit among the retries, got %v", counts)
+	}
+}
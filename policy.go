@@ -0,0 +1,101 @@
+package retryable
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+// Policy is a retry policy as plain data, to be unmarshaled from a configuration file and turned into a
+// Retrier with NewRetrierFromPolicy. In JSON, the delays are strings parsed by time.ParseDuration, such as
+// "500ms" or "1s", or integer numbers of nanoseconds, as they are marshaled.
+type Policy struct {
+	// MaxAttempts is the maximum number of attempts; zero means DefaultMaxAttempts.
+	MaxAttempts int `json:"maxAttempts" yaml:"maxAttempts"`
+	// BaseDelay is the delay after the first failed attempt.
+	BaseDelay time.Duration `json:"baseDelay" yaml:"baseDelay"`
+	// MaxDelay caps the delays growing with Multiplier; zero means no cap. It must not be below BaseDelay.
+	MaxDelay time.Duration `json:"maxDelay" yaml:"maxDelay"`
+	// Multiplier grows the delay after each failed attempt, and must then be at least 1. Zero keeps the
+	// delay constant at BaseDelay.
+	Multiplier float64 `json:"multiplier" yaml:"multiplier"`
+	// JitterFactor randomizes each delay by up to that fraction of it, see WithJitter.
+	JitterFactor float64 `json:"jitterFactor" yaml:"jitterFactor"`
+	// RetryableErrors restricts the retries to the errors whose message contains one of the patterns.
+	// When empty, every error is retried.
+	RetryableErrors []string `json:"retryableErrors" yaml:"retryableErrors"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler, accepting the delays as duration strings.
+func (p *Policy) UnmarshalJSON(data []byte) error {
+	type plain Policy // without the method, to decode the other fields as usual
+	aux := struct {
+		*plain
+		BaseDelay jsonDuration `json:"baseDelay"`
+		MaxDelay  jsonDuration `json:"maxDelay"`
+	}{plain: (*plain)(p), BaseDelay: jsonDuration(p.BaseDelay), MaxDelay: jsonDuration(p.MaxDelay)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	p.BaseDelay, p.MaxDelay = time.Duration(aux.BaseDelay), time.Duration(aux.MaxDelay)
+	return nil
+}
+
+// jsonDuration is a time.Duration decoded from a duration string or an integer number of nanoseconds.
+type jsonDuration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (d *jsonDuration) UnmarshalJSON(data []byte) error {
+	if !strings.HasPrefix(string(data), `"`) {
+		return json.Unmarshal(data, (*int64)(d))
+	}
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = jsonDuration(parsed)
+	return nil
+}
+
+// options returns the options equivalent to the policy.
+func (p Policy) options() []Option {
+	maxAttempts := p.MaxAttempts
+	if maxAttempts == 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+	opts := []Option{WithMaxAttempts(maxAttempts), WithJitter(p.JitterFactor)}
+	if p.Multiplier == 0 {
+		opts = append(opts, WithConstantBackoff(p.BaseDelay))
+	} else {
+		opts = append(opts, WithExponentialBackoff(p.BaseDelay, p.Multiplier, p.MaxDelay))
+	}
+	if len(p.RetryableErrors) > 0 {
+		opts = append(opts, WithRetryIf(NewMatcher(p.RetryableErrors).Match))
+	}
+	return opts
+}
+
+// NewRetrierFromPolicy validates the policy and builds a Retrier from it. It returns an error wrapping
+// ErrInvalidConfig for every bad value, as ValidateConfig does: a negative MaxAttempts, a negative delay,
+// a MaxDelay below BaseDelay, a Multiplier below 1 other than zero, or a JitterFactor outside [0, 1].
+func NewRetrierFromPolicy(p Policy) (*Retrier, error) {
+	opts := p.options()
+	var errs []error
+	if p.MaxDelay < 0 {
+		errs = append(errs, invalidConfig("max delay must not be negative, got %v", p.MaxDelay))
+	}
+	if p.Multiplier == 0 && p.MaxDelay > 0 && p.MaxDelay < p.BaseDelay {
+		// The constant backoff ignores MaxDelay, which the exponential one checks itself.
+		errs = append(errs, invalidConfig("base delay %v is greater than max delay %v", p.BaseDelay, p.MaxDelay))
+	}
+	errs = append(errs, ValidateConfig(opts...))
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return NewRetrier(opts...), nil
+}
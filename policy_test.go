@@ -0,0 +1,89 @@
+package retryable_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestNewRetrierFromPolicy tests that a policy unmarshaled from a configuration file drives the Retrier.
+func TestNewRetrierFromPolicy(t *testing.T) {
+	var p retryable.Policy
+	config := `{"MaxAttempts": 3, "BaseDelay": 1000000, "Multiplier": 2, "RetryableErrors": ["timeout"]}`
+	if err := json.Unmarshal([]byte(config), &p); err != nil {
+		t.Fatal(err)
+	}
+	r, err := retryable.NewRetrierFromPolicy(p)
+	if err != nil {
+		t.Fatalf("Expected a valid policy, got %v", err)
+	}
+
+	attempts := 0
+	r.Do(context.Background(), func(context.Context) error {
+		attempts++
+		return errors.New("i/o timeout")
+	})
+	if attempts != 3 {
+		t.Errorf("Expected 3 attempts on a retryable error, got %d", attempts)
+	}
+
+	attempts = 0
+	err = r.Do(context.Background(), func(context.Context) error {
+		attempts++
+		return errors.New("permission denied")
+	})
+	if attempts != 1 || !errors.Is(err, retryable.ErrNonRetryable) {
+		t.Errorf("Expected an error outside the list to stop the loop, got %v after %d attempts", err, attempts)
+	}
+}
+
+// TestNewRetrierFromPolicyInvalid tests that every bad value of a policy is reported.
+func TestNewRetrierFromPolicyInvalid(t *testing.T) {
+	r, err := retryable.NewRetrierFromPolicy(retryable.Policy{
+		MaxAttempts:  -1,
+		BaseDelay:    2 * time.Second,
+		MaxDelay:     1 * time.Second,
+		Multiplier:   0.5,
+		JitterFactor: 2,
+	})
+
+	if r != nil || !errors.Is(err, retryable.ErrInvalidConfig) {
+		t.Fatalf("Expected an invalid configuration error, got %v", err)
+	}
+	for _, reason := range []string{"max attempts", "multiplier", "greater than max delay", "jitter factor"} {
+		if !strings.Contains(err.Error(), reason) {
+			t.Errorf("Expected the error to mention %q, got %v", reason, err)
+		}
+	}
+
+	if _, err := retryable.NewRetrierFromPolicy(retryable.Policy{MaxDelay: -1}); !errors.Is(err, retryable.ErrInvalidConfig) {
+		t.Errorf("Expected a negative max delay to be rejected, got %v", err)
+	}
+	constant := retryable.Policy{BaseDelay: 2 * time.Second, MaxDelay: 1 * time.Second}
+	if _, err := retryable.NewRetrierFromPolicy(constant); !errors.Is(err, retryable.ErrInvalidConfig) {
+		t.Errorf("Expected a max delay below the base delay to be rejected without a multiplier, got %v", err)
+	}
+}
+
+// TestPolicyUnmarshalJSON tests that the delays of a policy decode from duration strings as well as nanoseconds.
+func TestPolicyUnmarshalJSON(t *testing.T) {
+	var p retryable.Policy
+	config := `{"maxAttempts": 4, "baseDelay": "250ms", "maxDelay": 2000000000, "multiplier": 2, "jitterFactor": 0.1}`
+	if err := json.Unmarshal([]byte(config), &p); err != nil {
+		t.Fatal(err)
+	}
+	expected := retryable.Policy{MaxAttempts: 4, BaseDelay: 250 * time.Millisecond, MaxDelay: 2 * time.Second, Multiplier: 2, JitterFactor: 0.1}
+	if p.MaxAttempts != expected.MaxAttempts || p.BaseDelay != expected.BaseDelay || p.MaxDelay != expected.MaxDelay ||
+		p.Multiplier != expected.Multiplier || p.JitterFactor != expected.JitterFactor {
+		t.Errorf("Expected %+v, got %+v", expected, p)
+	}
+
+	if err := json.Unmarshal([]byte(`{"baseDelay": "soon"}`), &p); err == nil {
+		t.Error("Expected an invalid duration to be rejected")
+	}
+}
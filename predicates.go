@@ -0,0 +1,34 @@
+package retryable
+
+import (
+	"errors"
+	"net"
+	"syscall"
+)
+
+// IsTimeout reports whether any error in the chain of err is a net.Error reporting a timeout.
+// It can be passed directly as the check of RetryWithCustomCheck or WithRetryIf.
+func IsTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
+// IsTemporary reports whether any error in the chain of err is a net.Error reporting a temporary condition.
+// net.Error.Temporary is deprecated because most temporary errors are timeouts and the remaining cases
+// are ill-defined, yet it is still widely returned; prefer IsTimeout or checks on specific error types
+// when possible.
+func IsTemporary(err error) bool {
+	var netErr interface{ Temporary() bool }
+	return errors.As(err, &netErr) && netErr.Temporary()
+}
+
+// IsPreSendFailure reports whether err shows that a request could not have reached the server, such as a
+// refused connection or another failed dial other than an unknown host.
+func IsPreSendFailure(err error) bool {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+		return false
+	}
+	var opErr *net.OpError
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.As(err, &opErr) && opErr.Op == "dial"
+}
@@ -0,0 +1,84 @@
+package retryable_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// fakeNetError implements net.Error with configurable flags.
+type fakeNetError struct {
+	timeout, temporary bool
+}
+
+func (e fakeNetError) Error() string   { return "fake network error" }
+func (e fakeNetError) Timeout() bool   { return e.timeout }
+func (e fakeNetError) Temporary() bool { return e.temporary }
+
+// TestIsTimeout tests the timeout detection through wrapped errors.
+func TestIsTimeout(t *testing.T) {
+	if !retryable.IsTimeout(fmt.Errorf("dial: %w", fakeNetError{timeout: true})) {
+		t.Errorf("Expected a wrapped timeout to be detected")
+	}
+	if retryable.IsTimeout(fakeNetError{temporary: true}) || retryable.IsTimeout(errors.New("timeout")) || retryable.IsTimeout(nil) {
+		t.Errorf("Expected non-timeout errors to not be detected")
+	}
+}
+
+// TestIsTemporary tests the temporary detection through wrapped errors.
+func TestIsTemporary(t *testing.T) {
+	if !retryable.IsTemporary(fmt.Errorf("read: %w", fakeNetError{temporary: true})) {
+		t.Errorf("Expected a wrapped temporary error to be detected")
+	}
+	if retryable.IsTemporary(fakeNetError{timeout: true}) || retryable.IsTemporary(errors.New("temporary")) {
+		t.Errorf("Expected non-temporary errors to not be detected")
+	}
+}
+
+// TestIsTimeoutWithCustomCheck tests plugging IsTimeout into RetryWithCustomCheck.
+func TestIsTimeoutWithCustomCheck(t *testing.T) {
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, fakeNetError{timeout: true}
+		}
+		return attempts, nil
+	}
+
+	result, err := retryable.RetryWithCustomCheck(fn, 5, 1*time.Millisecond, retryable.IsTimeout)
+	if err != nil || result != 3 {
+		t.Errorf("Expected timeouts to be retried, got %v with error %v", result, err)
+	}
+}
+
+// TestIsPreSendFailure tests that only the failures before the request could be sent are detected.
+func TestIsPreSendFailure(t *testing.T) {
+	preSend := []error{
+		fmt.Errorf("post: %w", syscall.ECONNREFUSED),
+		&net.OpError{Op: "dial", Net: "tcp", Err: fakeNetError{timeout: true}},
+		&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "server misbehaving", IsTemporary: true}},
+	}
+	for _, err := range preSend {
+		if !retryable.IsPreSendFailure(err) {
+			t.Errorf("Expected %v to be a pre-send failure", err)
+		}
+	}
+
+	postSend := []error{
+		&net.OpError{Op: "read", Net: "tcp", Err: fakeNetError{timeout: true}},
+		&net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET},
+		&net.OpError{Op: "dial", Net: "tcp", Err: &net.DNSError{Err: "no such host", IsNotFound: true}},
+		errors.New("connection refused"),
+	}
+	for _, err := range postSend {
+		if retryable.IsPreSendFailure(err) {
+			t.Errorf("Expected %v not to be a pre-send failure", err)
+		}
+	}
+}
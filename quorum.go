@@ -0,0 +1,59 @@
+package retryable
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrQuorumNotReached is wrapped by the error of RetryForQuorum when too few operations succeeded.
+var ErrQuorumNotReached = errors.New("retryable: quorum not reached")
+
+// RetryForQuorum calls the operations that have not succeeded yet concurrently, in up to maxAttempts rounds
+// separated by delay, until at least quorum of them have succeeded. It returns the results keyed by index
+// in ops and, if the quorum was not reached, an error wrapping ErrQuorumNotReached and the last errors.
+func RetryForQuorum[T any](ops []func() (T, error), quorum int, maxAttempts int, delay time.Duration) (map[int]T, error) {
+	maxAttempts = max(maxAttempts, 1)
+	results := make(map[int]T, len(ops))
+	values := make([]T, len(ops))
+	errs := make([]error, len(ops))
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		var wg sync.WaitGroup
+		for i, op := range ops {
+			if _, ok := results[i]; ok {
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				values[i], errs[i] = op()
+			}()
+		}
+		wg.Wait()
+		for i, err := range errs {
+			if _, ok := results[i]; !ok && err == nil {
+				results[i] = values[i]
+			}
+		}
+		if len(results) >= quorum || attempt == maxAttempts {
+			break
+		}
+
+		if logEnabled() {
+			logf("Attempt %d/%d reached %d/%d successes. Retrying in %v...", attempt, maxAttempts, len(results), quorum, delay)
+		}
+		if ctxErr := sleepDefault(delay); ctxErr != nil {
+			return results, errors.Join(ctxErr, quorumError(len(results), quorum, errs))
+		}
+	}
+	if len(results) >= quorum {
+		return results, nil
+	}
+	return results, quorumError(len(results), quorum, errs)
+}
+
+// quorumError builds the error of RetryForQuorum from the last errors of the operations.
+func quorumError(successes, quorum int, errs []error) error {
+	return fmt.Errorf("%w: %d/%d successes: %w", ErrQuorumNotReached, successes, quorum, errors.Join(errs...))
+}
@@ -0,0 +1,64 @@
+package retryable_test
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// replica returns an operation that fails the given number of times before returning its name.
+func replica(name string, failures int32, calls *atomic.Int32) func() (string, error) {
+	var n atomic.Int32
+	return func() (string, error) {
+		calls.Add(1)
+		if n.Add(1) <= failures {
+			return "", fmt.Errorf("%s unavailable", name)
+		}
+		return name, nil
+	}
+}
+
+// TestRetryForQuorum tests that the failing operations are retried until the quorum is reached, and no further.
+func TestRetryForQuorum(t *testing.T) {
+	var calls atomic.Int32
+	ops := []func() (string, error){
+		replica("a", 0, &calls),
+		replica("b", 1, &calls),
+		replica("c", 5, &calls),
+	}
+
+	results, err := retryable.RetryForQuorum(ops, 2, 5, 1*time.Millisecond)
+
+	if err != nil || len(results) != 2 || results[0] != "a" || results[1] != "b" {
+		t.Errorf("Expected the quorum with a and b, got %v, %v", results, err)
+	}
+	if n := calls.Load(); n != 5 {
+		t.Errorf("Expected 3 calls in the first round and 2 in the second, got %d", n)
+	}
+}
+
+// TestRetryForQuorumNotReached tests the error returned when too few operations succeed.
+func TestRetryForQuorumNotReached(t *testing.T) {
+	var calls atomic.Int32
+	ops := []func() (string, error){
+		replica("a", 0, &calls),
+		replica("b", 5, &calls),
+		replica("c", 5, &calls),
+	}
+
+	results, err := retryable.RetryForQuorum(ops, 2, 3, 1*time.Millisecond)
+
+	if len(results) != 1 || !errors.Is(err, retryable.ErrQuorumNotReached) {
+		t.Fatalf("Expected the quorum not to be reached, got %v, %v", results, err)
+	}
+	if err.Error() != "retryable: quorum not reached: 1/2 successes: b unavailable\nc unavailable" {
+		t.Errorf("Unexpected error %q", err)
+	}
+	if n := calls.Load(); n != 7 {
+		t.Errorf("Expected a to be called once and the others 3 times, got %d calls", n)
+	}
+}
@@ -0,0 +1,173 @@
+package retryable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	// ErrMaxAttemptsReached is matched by the *RetryError returned when every attempt failed.
+	ErrMaxAttemptsReached = errors.New("retryable: max attempts reached")
+
+	// ErrNonRetryable is matched by the errors returned when an error classified as non-retryable halted
+	// the retries, by the option-based functions as well as by the list-based and custom-check functions.
+	ErrNonRetryable = errors.New("retryable: non-retryable error")
+
+	// ErrNoProgress is matched by the *RetryError returned when WithNoProgressDetection aborted the loop.
+	ErrNoProgress = errors.New("retryable: no progress between attempts")
+
+	// ErrShutdown is matched by the *RetryError returned when the loop was stopped by Retrier.Shutdown.
+	ErrShutdown = errors.New("retryable: retrier shut down")
+
+	// ErrStoppedByPredicate is matched by the *RetryError returned when the function set with
+	// WithContinueFunc stopped the loop.
+	ErrStoppedByPredicate = errors.New("retryable: stopped by continue func")
+
+	// ErrCircuitOpen is matched by the *RetryError returned when the circuit of WithCircuitBreaker was open.
+	ErrCircuitOpen = errors.New("retryable: circuit open")
+
+	// ErrDependencyUnhealthy is matched by the *RetryError returned when the health check of WithAbortWhen
+	// reported the dependency as down.
+	ErrDependencyUnhealthy = errors.New("retryable: dependency unhealthy")
+)
+
+// outcomeErrors maps the outcomes to the sentinel errors matched by a *RetryError. Cancellations match the
+// errors of the context package, even when the deadline was a time budget rather than a context deadline.
+var outcomeErrors = map[Outcome]error{
+	OutcomeMaxAttempts:      ErrMaxAttemptsReached,
+	OutcomeNonRetryable:     ErrNonRetryable,
+	OutcomeCanceled:         context.Canceled,
+	OutcomeDeadlineExceeded: context.DeadlineExceeded,
+	OutcomeNoProgress:       ErrNoProgress,
+	OutcomeShutdown:         ErrShutdown,
+	OutcomeStopped:          ErrStoppedByPredicate,
+	OutcomeCircuitOpen:      ErrCircuitOpen,
+	OutcomeUnhealthy:        ErrDependencyUnhealthy,
+	OutcomeInvalidConfig:    ErrInvalidConfig,
+}
+
+// Outcome describes why a retry loop terminated.
+type Outcome int
+
+const (
+	// OutcomeSuccess means the function eventually succeeded.
+	OutcomeSuccess Outcome = iota
+	// OutcomeMaxAttempts means every attempt failed with a retryable error.
+	OutcomeMaxAttempts
+	// OutcomeNonRetryable means the loop stopped early on an error classified as non-retryable.
+	OutcomeNonRetryable
+	// OutcomeCanceled means the context was canceled before the function succeeded.
+	OutcomeCanceled
+	// OutcomeDeadlineExceeded means the context deadline passed before the function succeeded.
+	OutcomeDeadlineExceeded
+	// OutcomeNoProgress means the result did not change across attempts, see WithNoProgressDetection.
+	OutcomeNoProgress
+	// OutcomeShutdown means the Retrier running the loop was shut down.
+	OutcomeShutdown
+	// OutcomeStopped means the function set with WithContinueFunc stopped the loop.
+	OutcomeStopped
+	// OutcomeCircuitOpen means the circuit of WithCircuitBreaker was open.
+	OutcomeCircuitOpen
+	// OutcomeUnhealthy means the health check of WithAbortWhen reported the dependency as down.
+	OutcomeUnhealthy
+	// OutcomeInvalidConfig means strict mode rejected the configuration, see SetStrictMode.
+	OutcomeInvalidConfig
+)
+
+// String returns a human readable description of the outcome.
+func (o Outcome) String() string {
+	switch o {
+	case OutcomeSuccess:
+		return "success"
+	case OutcomeMaxAttempts:
+		return "max attempts reached"
+	case OutcomeNonRetryable:
+		return "non-retryable error"
+	case OutcomeCanceled:
+		return "context canceled"
+	case OutcomeDeadlineExceeded:
+		return "deadline exceeded"
+	case OutcomeNoProgress:
+		return "no progress"
+	case OutcomeShutdown:
+		return "shutdown"
+	case OutcomeStopped:
+		return "stopped"
+	case OutcomeCircuitOpen:
+		return "circuit open"
+	case OutcomeUnhealthy:
+		return "dependency unhealthy"
+	case OutcomeInvalidConfig:
+		return "invalid configuration"
+	default:
+		return fmt.Sprintf("Outcome(%d)", int(o))
+	}
+}
+
+// RetryStats describes how a retry loop went.
+type RetryStats struct {
+	// Attempts is the number of times the function was called.
+	Attempts int
+	// Outcome is the reason the loop terminated.
+	Outcome Outcome
+	// Elapsed is the total time spent in the loop, attempts and delays included.
+	Elapsed time.Duration
+	// Errors holds the error of every failed attempt, in order.
+	Errors []error
+	// ExecutionTime is the time spent in the calls to the function, the failed ones included.
+	ExecutionTime time.Duration
+	// SleepTime is the time spent waiting between attempts, including a wait cut short by cancellation.
+	// Elapsed is the sum of ExecutionTime and SleepTime plus the overhead of the loop, such as waiting for a
+	// slot of WithConcurrencyLimit, as measured by the configured Clock.
+	SleepTime time.Duration
+	// SuccessLatency is the duration of the successful call alone, excluding the failed attempts and the
+	// delays before it. It is zero unless the outcome is OutcomeSuccess.
+	SuccessLatency time.Duration
+}
+
+// RetryResult is the detailed result of a retry loop.
+type RetryResult[T any] struct {
+	// Value is the result of the last attempt.
+	Value T
+	// Err is nil on success, otherwise a *RetryError describing the termination.
+	Err error
+
+	RetryStats
+
+	// ctxErr is the context error when the context stopped the loop.
+	ctxErr error
+}
+
+// RetryError is the error returned by the option-based functions when the retry loop gives up.
+// It unwraps to the underlying error, so errors.Is and errors.As keep working on it, and exposes
+// the termination reason so callers can switch on it instead of matching error messages.
+type RetryError struct {
+	// Outcome is the reason the loop terminated, never OutcomeSuccess.
+	Outcome Outcome
+	// Attempts is the number of times the function was called.
+	Attempts int
+	// Err is the error that ended the loop: the last error returned by the function, or the first one
+	// with WithReturnFirstError, joined with the context error on cancellation.
+	Err error
+	// Errors holds the error of every failed attempt, in order, when WithAggregateErrors is set.
+	Errors []error
+}
+
+// Error implements the error interface.
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%v after %d attempt(s): %v", e.Outcome, e.Attempts, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *RetryError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is the sentinel error of the outcome, so that for instance
+// errors.Is(err, ErrMaxAttemptsReached) holds in addition to matching the underlying error.
+func (e *RetryError) Is(target error) bool {
+	sentinel, ok := outcomeErrors[e.Outcome]
+	return ok && target == sentinel
+}
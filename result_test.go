@@ -0,0 +1,293 @@
+package retryable_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestRetryResultOutcomeSuccess tests the outcome of a successful retry loop.
+func TestRetryResultOutcomeSuccess(t *testing.T) {
+	attempts := 0
+	fn := func(context.Context) (int, error) {
+		attempts++
+		if attempts < 2 {
+			return 0, errors.New("temporary error")
+		}
+		return attempts, nil
+	}
+
+	res := retryable.RetryWithResult(context.Background(), fn, retryable.WithDelay(1*time.Millisecond))
+	if res.Err != nil || res.Outcome != retryable.OutcomeSuccess || res.Attempts != 2 || res.Value != 2 {
+		t.Errorf("Expected success after 2 attempts, got %+v", res)
+	}
+}
+
+// TestRetryResultOutcomeMaxAttempts tests the outcome when every attempt fails.
+func TestRetryResultOutcomeMaxAttempts(t *testing.T) {
+	errBoom := errors.New("boom")
+	fn := func(context.Context) (int, error) {
+		return 0, errBoom
+	}
+
+	res := retryable.RetryWithResult(context.Background(), fn, retryable.WithMaxAttempts(3), retryable.WithDelay(1*time.Millisecond))
+	if res.Outcome != retryable.OutcomeMaxAttempts || res.Attempts != 3 {
+		t.Errorf("Expected max attempts outcome after 3 attempts, got %v after %d", res.Outcome, res.Attempts)
+	}
+
+	var retryErr *retryable.RetryError
+	if !errors.As(res.Err, &retryErr) || retryErr.Outcome != retryable.OutcomeMaxAttempts || !errors.Is(res.Err, errBoom) {
+		t.Errorf("Expected a *RetryError wrapping the last error, got %v", res.Err)
+	}
+}
+
+// TestRetryResultOutcomeZeroMaxAttempts tests that a maximum below 1 makes a single attempt, whose error is wrapped.
+func TestRetryResultOutcomeZeroMaxAttempts(t *testing.T) {
+	errBoom := errors.New("boom")
+	fn := func(context.Context) (int, error) {
+		return 0, errBoom
+	}
+
+	res := retryable.RetryWithResult(context.Background(), fn, retryable.WithMaxAttempts(0), retryable.WithDelay(1*time.Millisecond))
+	if res.Outcome != retryable.OutcomeMaxAttempts || res.Attempts != 1 || !errors.Is(res.Err, errBoom) {
+		t.Errorf("Expected a single failed attempt, got %v after %d attempts: %v", res.Outcome, res.Attempts, res.Err)
+	}
+}
+
+// TestRetryResultOutcomeNonRetryable tests the outcome when the custom check rejects the error.
+func TestRetryResultOutcomeNonRetryable(t *testing.T) {
+	attempts := 0
+	fn := func(context.Context) (int, error) {
+		attempts++
+		return 0, errors.New("fatal error")
+	}
+
+	res := retryable.RetryWithResult(context.Background(), fn,
+		retryable.WithDelay(1*time.Millisecond),
+		retryable.WithRetryIf(func(err error) bool { return err.Error() != "fatal error" }),
+	)
+	if res.Outcome != retryable.OutcomeNonRetryable || attempts != 1 {
+		t.Errorf("Expected non-retryable outcome after 1 attempt, got %v after %d", res.Outcome, attempts)
+	}
+}
+
+// TestRetryResultOutcomeCanceled tests the outcome when the context is canceled between attempts.
+func TestRetryResultOutcomeCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	errBoom := errors.New("boom")
+	fn := func(context.Context) (int, error) {
+		cancel()
+		return 0, errBoom
+	}
+
+	_, err := retryable.RetryWithContext(ctx, fn, retryable.WithDelay(1*time.Second))
+
+	var retryErr *retryable.RetryError
+	if !errors.As(err, &retryErr) || retryErr.Outcome != retryable.OutcomeCanceled || retryErr.Attempts != 1 {
+		t.Fatalf("Expected canceled outcome after 1 attempt, got %v", err)
+	}
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, errBoom) {
+		t.Errorf("Expected both the context error and the last error, got %v", err)
+	}
+}
+
+// TestRetryResultOutcomeDeadlineExceeded tests the outcome when the context deadline passes.
+func TestRetryResultOutcomeDeadlineExceeded(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+
+	fn := func(context.Context) (int, error) {
+		return 0, errors.New("boom")
+	}
+
+	res := retryable.RetryWithResult(ctx, fn, retryable.WithMaxAttempts(100), retryable.WithDelay(2*time.Millisecond))
+	if res.Outcome != retryable.OutcomeDeadlineExceeded || !errors.Is(res.Err, context.DeadlineExceeded) {
+		t.Errorf("Expected deadline exceeded outcome, got %v with error %v", res.Outcome, res.Err)
+	}
+}
+
+// TestRetryResultOutcomeCanceledBeforeFirstAttempt tests that a done context prevents any attempt.
+func TestRetryResultOutcomeCanceledBeforeFirstAttempt(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	attempts := 0
+	fn := func(context.Context) (int, error) {
+		attempts++
+		return 0, nil
+	}
+
+	res := retryable.RetryWithResult(ctx, fn)
+	if res.Outcome != retryable.OutcomeCanceled || attempts != 0 || !errors.Is(res.Err, context.Canceled) {
+		t.Errorf("Expected canceled outcome without attempts, got %v after %d attempts", res.Outcome, attempts)
+	}
+}
+
+// TestRetryWithContextNearDeadline tests that no doomed attempt is started close to the context deadline.
+func TestRetryWithContextNearDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	attempts := 0
+	fn := func(context.Context) (int, error) {
+		attempts++
+		return 0, errors.New("boom")
+	}
+
+	start := time.Now()
+	res := retryable.RetryWithResult(ctx, fn, retryable.WithMaxAttempts(10), retryable.WithDelay(100*time.Millisecond))
+	if res.Outcome != retryable.OutcomeDeadlineExceeded || attempts != 1 || !errors.Is(res.Err, context.DeadlineExceeded) {
+		t.Errorf("Expected to give up after 1 attempt, got %v after %d attempts with error %v", res.Outcome, attempts, res.Err)
+	}
+	if elapsed := time.Since(start); elapsed > 40*time.Millisecond {
+		t.Errorf("Expected to return without waiting for the deadline, took %v", elapsed)
+	}
+}
+
+// TestRetryWithContextEstimatedAttemptDuration tests that the last attempt duration is used to skip doomed attempts.
+func TestRetryWithContextEstimatedAttemptDuration(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+
+	attempts := 0
+	fn := func(context.Context) (int, error) {
+		attempts++
+		time.Sleep(100 * time.Millisecond)
+		return 0, errors.New("slow failure")
+	}
+
+	res := retryable.RetryWithResult(ctx, fn, retryable.WithMaxAttempts(10), retryable.WithDelay(20*time.Millisecond))
+	if res.Outcome != retryable.OutcomeDeadlineExceeded || attempts != 2 {
+		t.Errorf("Expected to give up after 2 attempts, got %v after %d attempts", res.Outcome, attempts)
+	}
+}
+
+// TestRetryWithContextAndStats tests the stats returned alongside the result.
+func TestRetryWithContextAndStats(t *testing.T) {
+	clock := newFakeClock()
+	attempts := 0
+	fn := func(context.Context) (int, error) {
+		attempts++
+		clock.Advance(100 * time.Millisecond)
+		if attempts < 3 {
+			return 0, errors.New("temporary error")
+		}
+		return attempts, nil
+	}
+
+	var hooked int
+	result, stats, err := retryable.RetryWithContextAndStats(context.Background(), fn,
+		retryable.WithClock(clock),
+		retryable.WithExponentialBackoff(1*time.Second, 2, 0),
+		retryable.WithAfterAttempt(func(int, error, time.Duration) { hooked++ }),
+	)
+	if err != nil || result != 3 {
+		t.Fatalf("Expected success on the third attempt, got %v with error %v", result, err)
+	}
+	if stats.Attempts != 3 || stats.Outcome != retryable.OutcomeSuccess || len(stats.Errors) != 2 || hooked != 3 {
+		t.Errorf("Unexpected stats %+v after %d hooks", stats, hooked)
+	}
+	if expected := 300*time.Millisecond + 3*time.Second; stats.Elapsed != expected {
+		t.Errorf("Expected %v elapsed, got %v", expected, stats.Elapsed)
+	}
+}
+
+// TestRetryWithContextAndStatsCanceled tests that the stats are filled when the loop is canceled.
+func TestRetryWithContextAndStatsCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	fn := func(context.Context) (int, error) {
+		cancel()
+		return 0, errors.New("boom")
+	}
+
+	_, stats, err := retryable.RetryWithContextAndStats(ctx, fn, retryable.WithDelay(1*time.Hour))
+	if !errors.Is(err, context.Canceled) || stats.Outcome != retryable.OutcomeCanceled || stats.Attempts != 1 || len(stats.Errors) != 1 {
+		t.Errorf("Unexpected stats %+v with error %v", stats, err)
+	}
+}
+
+// TestRetryErrorSentinels tests that each termination reason of the option-based functions has a distinct sentinel.
+func TestRetryErrorSentinels(t *testing.T) {
+	errBoom := errors.New("boom")
+	fail := func(context.Context) (int, error) { return 0, errBoom }
+
+	_, err := retryable.RetryWithContext(context.Background(), fail, retryable.WithMaxAttempts(2), retryable.WithDelay(1*time.Millisecond))
+	if !errors.Is(err, retryable.ErrMaxAttemptsReached) || errors.Is(err, retryable.ErrNonRetryable) || !errors.Is(err, errBoom) {
+		t.Errorf("Expected only ErrMaxAttemptsReached, got %v", err)
+	}
+
+	_, err = retryable.RetryWithContext(context.Background(), fail, retryable.WithRetryIf(func(error) bool { return false }))
+	if !errors.Is(err, retryable.ErrNonRetryable) || errors.Is(err, retryable.ErrMaxAttemptsReached) || !errors.Is(err, errBoom) {
+		t.Errorf("Expected only ErrNonRetryable, got %v", err)
+	}
+
+	_, err = retryable.RetryWithDeadline(func() (int, error) { return 0, errBoom }, 1*time.Second,
+		retryable.WithDelay(1*time.Second), retryable.WithClock(newFakeClock()))
+	if !errors.Is(err, context.DeadlineExceeded) || errors.Is(err, retryable.ErrMaxAttemptsReached) {
+		t.Errorf("Expected an exhausted time budget to match context.DeadlineExceeded, got %v", err)
+	}
+}
+
+// TestRetryResultSuccessLatency tests that only the successful call is measured, and only on success.
+func TestRetryResultSuccessLatency(t *testing.T) {
+	clock := newFakeClock()
+	attempts := 0
+	res := retryable.RetryWithResult(context.Background(), func(context.Context) (int, error) {
+		attempts++
+		if attempts < 3 {
+			clock.Advance(5 * time.Second)
+			return 0, errors.New("slow failure")
+		}
+		clock.Advance(200 * time.Millisecond)
+		return 1, nil
+	}, retryable.WithMaxAttempts(3), retryable.WithDelay(1*time.Second), retryable.WithClock(clock))
+
+	if res.SuccessLatency != 200*time.Millisecond {
+		t.Errorf("Expected a success latency of 200ms, got %v", res.SuccessLatency)
+	}
+	if res.Elapsed != 12200*time.Millisecond {
+		t.Errorf("Expected an elapsed time of 12.2s, got %v", res.Elapsed)
+	}
+
+	res = retryable.RetryWithResult(context.Background(), func(context.Context) (int, error) {
+		clock.Advance(1 * time.Second)
+		return 0, errors.New("failure")
+	}, retryable.WithMaxAttempts(2), retryable.WithDelay(1*time.Second), retryable.WithClock(clock))
+	if res.SuccessLatency != 0 {
+		t.Errorf("Expected no success latency on failure, got %v", res.SuccessLatency)
+	}
+}
+
+// TestRetryResultExecutionAndSleepTime tests that the elapsed time is split between the calls and the delays.
+func TestRetryResultExecutionAndSleepTime(t *testing.T) {
+	clock := newFakeClock()
+	res := retryable.RetryWithResult(context.Background(), func(context.Context) (int, error) {
+		clock.Advance(2 * time.Second)
+		return 0, errors.New("slow failure")
+	}, retryable.WithMaxAttempts(3), retryable.WithDelay(5*time.Second), retryable.WithClock(clock))
+
+	if res.ExecutionTime != 6*time.Second || res.SleepTime != 10*time.Second || res.Elapsed != res.ExecutionTime+res.SleepTime {
+		t.Errorf("Expected 6s executing and 10s sleeping, got %+v", res.RetryStats)
+	}
+}
+
+// TestRetryResultExecutionAndSleepTimeCanceled tests that both times are filled when the context is canceled during a delay.
+func TestRetryResultExecutionAndSleepTimeCanceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	time.AfterFunc(25*time.Millisecond, cancel)
+	res := retryable.RetryWithResult(ctx, func(context.Context) (int, error) {
+		time.Sleep(10 * time.Millisecond)
+		return 0, errors.New("slow failure")
+	}, retryable.WithDelay(1*time.Hour))
+
+	if res.Outcome != retryable.OutcomeCanceled || res.ExecutionTime < 10*time.Millisecond || res.SleepTime < 10*time.Millisecond {
+		t.Fatalf("Expected both times to be filled, got %+v", res.RetryStats)
+	}
+	if overhead := res.Elapsed - res.ExecutionTime - res.SleepTime; overhead < 0 || overhead > 5*time.Millisecond {
+		t.Errorf("Expected the times to sum to the elapsed time, got %+v", res.RetryStats)
+	}
+}
@@ -0,0 +1,145 @@
+package retryable
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Retrier is a retry policy built once from options and reused across calls.
+// It is safe for concurrent use, and the options that keep state between calls, such as
+// WithAdaptiveAttemptTimeout, share that state across every call made through the same Retrier.
+type Retrier struct {
+	opts         []Option
+	streak       *failureStreak
+	done         chan struct{}
+	shutdownOnce sync.Once
+}
+
+// NewRetrier builds a Retrier from the options, applied on top of the package defaults.
+func NewRetrier(opts ...Option) *Retrier {
+	return &Retrier{opts: opts, streak: &failureStreak{}, done: make(chan struct{})}
+}
+
+// Clone returns a new Retrier with the options of r followed by the overrides, e.g.
+// r.Clone(WithMaxAttempts(10)). The clone has its own failure streak and shutdown, but shares the state
+// held by the options, such as a CircuitBreaker.
+func (r *Retrier) Clone(overrides ...Option) *Retrier {
+	opts := append(append([]Option(nil), r.opts...), overrides...)
+	return NewRetrier(opts...)
+}
+
+// Shutdown stops the retry loops of the Retrier, current and future, at their next wait or attempt,
+// returning a *RetryError matching ErrShutdown. Attempts in flight complete normally.
+func (r *Retrier) Shutdown() {
+	r.shutdownOnce.Do(func() {
+		close(r.done)
+	})
+}
+
+// Reset clears the failure streak shared by the calls of the Retrier when WithStatefulBackoff is set,
+// so the next failure starts the backoff progression over.
+func (r *Retrier) Reset() {
+	r.streak.reset()
+}
+
+// Do executes the provided function with the policy of the Retrier, like RetryWithContext.
+func (r *Retrier) Do(ctx context.Context, fn func(context.Context) error) error {
+	_, err := RetryWithRetrier(ctx, r, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, fn(ctx)
+	})
+	return err
+}
+
+// RetryWithRetrier executes the provided function with the policy of the Retrier, like RetryWithContext.
+// It is the generic counterpart of Retrier.Do for functions returning a value.
+func RetryWithRetrier[T any](ctx context.Context, r *Retrier, fn func(context.Context) (T, error)) (T, error) {
+	res := retryLoop(ctx, fn, r.config())
+	return res.Value, res.Err
+}
+
+// config builds the configuration of a single call.
+func (r *Retrier) config() *config {
+	c := newConfig(r.opts)
+	c.shutdown = r.done
+	if c.statefulBackoff {
+		c.streak = r.streak
+	}
+	return c
+}
+
+// WithStatefulBackoff makes the delays of a Retrier grow with the streak of consecutive failures across its
+// calls rather than with the attempt number of each call. A success resets the streak, as does
+// Retrier.Reset.
+func WithStatefulBackoff() Option {
+	return func(c *config) {
+		c.statefulBackoff = true
+	}
+}
+
+// WithStreakDecay makes the failure streak of WithStatefulBackoff decay: when more than after has
+// elapsed since the last failure recorded in the streak, as measured by the configured Clock, the next
+// failure starts a new streak, so an outage long past does not slow down a fresh operation.
+// The duration must be positive.
+func WithStreakDecay(after time.Duration) Option {
+	return func(c *config) {
+		if after <= 0 {
+			c.invalid("streak decay must be positive, got %v", after)
+		}
+		c.streakDecay = after
+	}
+}
+
+// WithResetAfterSuccesses makes the streak of WithStatefulBackoff reset only after n consecutive successful
+// calls. n must be at least 1, the default.
+func WithResetAfterSuccesses(n int) Option {
+	return func(c *config) {
+		if n < 1 {
+			c.invalid("successes before a reset must be at least 1, got %d", n)
+		}
+		c.resetAfterSuccesses = n
+	}
+}
+
+// failureStreak counts consecutive failures shared across calls.
+type failureStreak struct {
+	mu          sync.Mutex
+	failures    int
+	lastFailure time.Time
+
+	// successes counts the consecutive successes since the last failure.
+	successes int
+}
+
+// fail records a failure happening at now and returns the length of the streak, starting a new streak
+// if the last failure is older than decay, when positive.
+func (f *failureStreak) fail(now time.Time, decay time.Duration) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if decay > 0 && f.failures > 0 && now.Sub(f.lastFailure) > decay {
+		f.failures = 0
+	}
+	f.failures++
+	f.successes = 0
+	f.lastFailure = now
+	return f.failures
+}
+
+// succeed records a success, resetting the streak once there have been n consecutive successes.
+func (f *failureStreak) succeed(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.successes++
+	if f.successes >= n {
+		f.failures = 0
+		f.successes = 0
+	}
+}
+
+// reset clears the streak.
+func (f *failureStreak) reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failures = 0
+	f.successes = 0
+}
@@ -0,0 +1,260 @@
+package retryable_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestRetrierDo tests that a Retrier applies its policy to every call.
+func TestRetrierDo(t *testing.T) {
+	r := retryable.NewRetrier(retryable.WithMaxAttempts(4), retryable.WithDelay(1*time.Millisecond))
+
+	for i := 0; i < 2; i++ {
+		attempts := 0
+		err := r.Do(context.Background(), func(context.Context) error {
+			attempts++
+			return errors.New("error")
+		})
+		if err == nil || attempts != 4 {
+			t.Errorf("Call %d: expected 4 failed attempts, got %d with error %v", i, attempts, err)
+		}
+	}
+}
+
+// TestRetryWithRetrier tests the generic entry point of a Retrier.
+func TestRetryWithRetrier(t *testing.T) {
+	r := retryable.NewRetrier(retryable.WithDelay(1 * time.Millisecond))
+	attempts := 0
+	fn := func(context.Context) (int, error) {
+		attempts++
+		if attempts < 2 {
+			return 0, errors.New("temporary error")
+		}
+		return attempts, nil
+	}
+
+	result, err := retryable.RetryWithRetrier(context.Background(), r, fn)
+	if err != nil || result != 2 {
+		t.Errorf("Expected success on the second attempt, got %v with error %v", result, err)
+	}
+}
+
+// TestWithStatefulBackoff tests that the backoff escalates across calls and resets after a success.
+func TestWithStatefulBackoff(t *testing.T) {
+	var delays []time.Duration
+	r := retryable.NewRetrier(
+		retryable.WithMaxAttempts(2),
+		retryable.WithStatefulBackoff(),
+		retryable.WithBackoff(retryable.BackoffFunc(func(attempt int) time.Duration {
+			delays = append(delays, time.Duration(attempt)*time.Microsecond)
+			return time.Duration(attempt) * time.Microsecond
+		})),
+	)
+	fail := func(context.Context) error { return errors.New("down") }
+
+	r.Do(context.Background(), fail)
+	r.Do(context.Background(), fail)
+	r.Do(context.Background(), func(context.Context) error { return nil })
+	r.Do(context.Background(), fail)
+
+	expected := []time.Duration{1 * time.Microsecond, 3 * time.Microsecond, 1 * time.Microsecond}
+	if fmt.Sprint(delays) != fmt.Sprint(expected) {
+		t.Errorf("Expected delays %v, got %v", expected, delays)
+	}
+}
+
+// TestWithStreakDecay tests that the shared failure streak starts over after a quiet period.
+func TestWithStreakDecay(t *testing.T) {
+	clock := newFakeClock()
+	var delays []time.Duration
+	r := retryable.NewRetrier(
+		retryable.WithMaxAttempts(2),
+		retryable.WithStatefulBackoff(),
+		retryable.WithStreakDecay(1*time.Minute),
+		retryable.WithClock(clock),
+		retryable.WithBackoff(retryable.BackoffFunc(func(attempt int) time.Duration {
+			delays = append(delays, time.Duration(attempt)*time.Second)
+			return time.Duration(attempt) * time.Second
+		})),
+	)
+	fail := func(context.Context) error { return errors.New("down") }
+
+	r.Do(context.Background(), fail)
+	clock.Advance(30 * time.Second)
+	r.Do(context.Background(), fail)
+	clock.Advance(2 * time.Minute)
+	r.Do(context.Background(), fail)
+
+	expected := []time.Duration{1 * time.Second, 3 * time.Second, 1 * time.Second}
+	if fmt.Sprint(delays) != fmt.Sprint(expected) {
+		t.Errorf("Expected delays %v, got %v", expected, delays)
+	}
+}
+
+// TestRetrierReset tests that Reset clears the shared failure streak.
+func TestRetrierReset(t *testing.T) {
+	var last int
+	r := retryable.NewRetrier(
+		retryable.WithMaxAttempts(2),
+		retryable.WithStatefulBackoff(),
+		retryable.WithBackoff(retryable.BackoffFunc(func(attempt int) time.Duration {
+			last = attempt
+			return 0
+		})),
+	)
+	fail := func(context.Context) error { return errors.New("down") }
+
+	r.Do(context.Background(), fail)
+	r.Do(context.Background(), fail)
+	if last != 3 {
+		t.Fatalf("Expected the streak to reach 3, got %d", last)
+	}
+
+	r.Reset()
+	r.Do(context.Background(), fail)
+	if last != 1 {
+		t.Errorf("Expected the streak to restart at 1 after Reset, got %d", last)
+	}
+}
+
+// TestWithStatefulBackoffConcurrent tests that the shared streak is safe for concurrent calls.
+func TestWithStatefulBackoffConcurrent(t *testing.T) {
+	r := retryable.NewRetrier(retryable.WithMaxAttempts(3), retryable.WithStatefulBackoff(), retryable.WithDelay(0))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			r.Do(context.Background(), func(context.Context) error {
+				if i%2 == 0 {
+					return nil
+				}
+				return errors.New("down")
+			})
+		}(i)
+	}
+	wg.Wait()
+}
+
+// TestRetrierShutdown tests that Shutdown interrupts current sleeps and refuses future calls.
+func TestRetrierShutdown(t *testing.T) {
+	r := retryable.NewRetrier(retryable.WithMaxAttempts(10), retryable.WithDelay(1*time.Hour))
+	errDown := errors.New("down")
+
+	started := make(chan struct{})
+	done := make(chan error)
+	go func() {
+		done <- r.Do(context.Background(), func(context.Context) error {
+			close(started)
+			return errDown
+		})
+	}()
+
+	<-started
+	r.Shutdown()
+	select {
+	case err := <-done:
+		if !errors.Is(err, retryable.ErrShutdown) || !errors.Is(err, errDown) {
+			t.Errorf("Expected ErrShutdown wrapping the last error, got %v", err)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatalf("Expected the sleep to be interrupted by Shutdown")
+	}
+
+	attempts := 0
+	err := r.Do(context.Background(), func(context.Context) error {
+		attempts++
+		return nil
+	})
+	if !errors.Is(err, retryable.ErrShutdown) || attempts != 0 {
+		t.Errorf("Expected future calls to fail with ErrShutdown without attempts, got %v after %d attempts", err, attempts)
+	}
+	r.Shutdown()
+}
+
+// TestRetrierShutdownInFlightAttempt tests that an attempt in flight completes normally.
+func TestRetrierShutdownInFlightAttempt(t *testing.T) {
+	r := retryable.NewRetrier(retryable.WithDelay(1 * time.Millisecond))
+	result, err := retryable.RetryWithRetrier(context.Background(), r, func(ctx context.Context) (int, error) {
+		r.Shutdown()
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		return 42, nil
+	})
+	if err != nil || result != 42 {
+		t.Errorf("Expected the in-flight attempt to complete, got %v with error %v", result, err)
+	}
+}
+
+// TestRetrierClone tests that a clone applies its overrides on top of the base policy without affecting the base.
+func TestRetrierClone(t *testing.T) {
+	base := retryable.NewRetrier(retryable.WithMaxAttempts(2), retryable.WithDelay(1*time.Millisecond))
+	clone := base.Clone(retryable.WithMaxAttempts(4))
+	countAttempts := func(r *retryable.Retrier) (int, error) {
+		attempts := 0
+		err := r.Do(context.Background(), func(context.Context) error {
+			attempts++
+			return errors.New("down")
+		})
+		return attempts, err
+	}
+
+	if attempts, _ := countAttempts(clone); attempts != 4 {
+		t.Errorf("Expected the clone to make 4 attempts, got %d", attempts)
+	}
+	if attempts, _ := countAttempts(base); attempts != 2 {
+		t.Errorf("Expected the base to keep making 2 attempts, got %d", attempts)
+	}
+
+	clone.Shutdown()
+	if _, err := countAttempts(clone); !errors.Is(err, retryable.ErrShutdown) {
+		t.Errorf("Expected the clone to be shut down, got %v", err)
+	}
+	if attempts, err := countAttempts(base); attempts != 2 || errors.Is(err, retryable.ErrShutdown) {
+		t.Errorf("Expected the base to keep running after the clone is shut down, got %v after %d attempts", err, attempts)
+	}
+}
+
+// TestWithResetAfterSuccesses tests that a flapping downstream only resets the shared failure streak after a run of successes.
+func TestWithResetAfterSuccesses(t *testing.T) {
+	var delays []time.Duration
+	r := retryable.NewRetrier(
+		retryable.WithMaxAttempts(2),
+		retryable.WithStatefulBackoff(),
+		retryable.WithResetAfterSuccesses(2),
+		retryable.WithClock(newFakeClock()),
+		retryable.WithBackoff(retryable.BackoffFunc(func(attempt int) time.Duration {
+			delays = append(delays, time.Duration(attempt)*time.Second)
+			return time.Duration(attempt) * time.Second
+		})),
+	)
+	call := func(outcomes ...bool) {
+		attempt := 0
+		r.Do(context.Background(), func(context.Context) error {
+			attempt++
+			if outcomes[attempt-1] {
+				return nil
+			}
+			return errors.New("down")
+		})
+	}
+
+	call(false, false) // streak 2
+	call(true)         // a single success does not reset it
+	call(false, true)  // streak 3, then one success
+	call(true)         // the second success in a row resets it
+	call(false, true)  // streak 1
+
+	expected := []time.Duration{1 * time.Second, 3 * time.Second, 1 * time.Second}
+	if fmt.Sprint(delays) != fmt.Sprint(expected) {
+		t.Errorf("Expected delays %v, got %v", expected, delays)
+	}
+}
@@ -1,37 +1,134 @@
 package retryable
 
 import (
+	"errors"
+	"fmt"
 	"log"
+	"slices"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
 var (
-	// DefaultMaxAttempts is the default maximum number of attempts for the retry operations.
+	// DefaultMaxAttempts is the default maximum number of attempts for the retry operations. A maximum
+	// below 1, here or given to any function, counts as 1, so the function is always called.
 	DefaultMaxAttempts int = 3
 
 	// DefaultDelay is the default time to wait before retrying an operation.
 	DefaultDelay time.Duration = 1 * time.Second
 
+	// ErrUnstableSuccess is returned by RetryUntilStableSuccess when the attempts run out before the
+	// required number of consecutive successes, without any attempt having failed.
+	ErrUnstableSuccess = errors.New("retryable: not enough consecutive successes")
+
 	// logPrintf is the default log output function to handle formatted log messages.
 	logPrintf func(string, ...interface{}) = log.Printf
+
+	// loggingDisabled turns logf into a no-op when set.
+	loggingDisabled atomic.Bool
+
+	// defaultBackoff holds the strategy set with SetDefaultBackoff, nil until then.
+	defaultBackoff atomic.Pointer[BackoffStrategy]
 )
 
+// DefaultBackoff returns the backoff strategy used by MustRetry and the other Must functions, and by the
+// option-based functions unless a backoff option is given. It is the strategy set with SetDefaultBackoff,
+// or a constant backoff of DefaultDelay, read at each call, if none was set.
+func DefaultBackoff() BackoffStrategy {
+	if strategy := defaultBackoff.Load(); strategy != nil {
+		return *strategy
+	}
+	return ConstantBackoff(DefaultDelay)
+}
+
+// SetDefaultBackoff sets the strategy returned by DefaultBackoff, e.g. an exponential backoff with jitter
+// as an organization-wide default. A nil strategy restores the constant backoff of DefaultDelay.
+// It is safe to call concurrently with the retry functions.
+func SetDefaultBackoff(strategy BackoffStrategy) {
+	if strategy == nil {
+		defaultBackoff.Store(nil)
+		return
+	}
+	defaultBackoff.Store(&strategy)
+}
+
 // SetLoggerWriter sets a custom log output function to handle formatted log messages.
 // writer: Function with signature matching log.Printf to output log messages.
 func SetLoggerWriter(writer func(string, ...interface{})) {
 	logPrintf = writer
 }
 
+// DisableLogging stops the package from logging. Unlike setting a no-op writer with SetLoggerWriter,
+// log messages are not even formatted while logging is disabled.
+func DisableLogging() {
+	loggingDisabled.Store(true)
+}
+
+// EnableLogging restores logging after DisableLogging. Logging is enabled by default.
+func EnableLogging() {
+	loggingDisabled.Store(false)
+}
+
+// logf formats and outputs a log message through logPrintf, unless logging is disabled.
+// Call sites check logEnabled first, since building the arguments allocates even when they are discarded.
+func logf(format string, args ...interface{}) {
+	if !logEnabled() {
+		return
+	}
+	logPrintf(format, args...)
+}
+
+// logEnabled reports whether logf outputs messages.
+func logEnabled() bool {
+	return !loggingDisabled.Load()
+}
+
 // MustRetry executes a function until it succeeds or the maximum number of attempts is reached.
-// It uses the global variables DefaultMaxAttempts and DefaultDelay for the retry configuration.
+// It uses DefaultMaxAttempts and DefaultBackoff for the retry configuration.
 func MustRetry[T any](fn func() (T, error)) (T, error) {
-	return Retry(fn, DefaultMaxAttempts, DefaultDelay)
+	return retryBackoff(fn, DefaultMaxAttempts, DefaultBackoff())
 }
 
 // Retry attempts to execute the provided function up to a maximum number of times, pausing with a delay between each try, regardless of the error type.
 // It's a relentless retry strategy that stops only when a success is achieved or the maxAttempts are exhausted.
+// An error matching io.EOF is returned at once, matching ErrNonRetryable, unless marked with Retryable.
 func Retry[T any](fn func() (T, error), maxAttempts int, delay time.Duration) (T, error) {
+	return retryBackoff(fn, maxAttempts, ConstantBackoff(delay))
+}
+
+// retryBackoff is like Retry but waits between attempts according to the backoff strategy.
+func retryBackoff[T any](fn func() (T, error), maxAttempts int, backoff BackoffStrategy) (T, error) {
+	maxAttempts = max(maxAttempts, 1)
+	var result T
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		result, err = fn()
+		if err == nil {
+			return result, nil
+		}
+		if isEOF(err) {
+			return result, nonRetryable(err) // io.EOF means the end of a stream, not a transient failure.
+		}
+		if attempt == maxAttempts {
+			break
+		}
+		delay := backoff.Delay(attempt)
+		if logEnabled() {
+			logf("Attempt %d/%d failed: %v. Retrying in %v...", attempt, maxAttempts, err, delay)
+		}
+		if ctxErr := sleepDefault(delay); ctxErr != nil {
+			return result, errors.Join(ctxErr, err)
+		}
+	}
+	return result, err // Return the last error encountered
+}
+
+// RetryWithSleeper is like Retry but waits between attempts with the provided sleep function instead of
+// time.Sleep. Tests can pass a recording sleeper to run without real waiting, while production code passes
+// time.Sleep. Like Retry, it does not retry io.EOF.
+func RetryWithSleeper[T any](fn func() (T, error), maxAttempts int, delay time.Duration, sleep func(time.Duration)) (T, error) {
+	maxAttempts = max(maxAttempts, 1)
 	var result T
 	var err error
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
@@ -39,21 +136,36 @@ func Retry[T any](fn func() (T, error), maxAttempts int, delay time.Duration) (T
 		if err == nil {
 			return result, nil
 		}
-		logPrintf("Attempt %d/%d failed: %v. Retrying in %v...", attempt, maxAttempts, err, delay)
-		time.Sleep(delay)
+		if isEOF(err) {
+			return result, nonRetryable(err) // io.EOF means the end of a stream, not a transient failure.
+		}
+		if logEnabled() {
+			logf("Attempt %d/%d failed: %v. Retrying in %v...", attempt, maxAttempts, err, delay)
+		}
+		sleep(delay)
 	}
 	return result, err // Return the last error encountered
 }
 
 // MustRetryWithCustomCheck executes a function until it succeeds, the maximum number of attempts is reached,
 // or the provided custom check function returns false indicating that the error is not retryable.
+// It uses DefaultMaxAttempts and DefaultBackoff for the retry configuration.
 func MustRetryWithCustomCheck[T any](fn func() (T, error), isRetryable func(error) bool) (T, error) {
-	return RetryWithCustomCheck(fn, DefaultMaxAttempts, DefaultDelay, isRetryable)
+	return retryWithCustomCheckBackoff(fn, DefaultMaxAttempts, DefaultBackoff(), isRetryable)
 }
 
 // RetryWithCustomCheck provides a flexible retry mechanism, allowing custom logic to determine retryable errors.
 // It retries a specified function with controlled delays and a user-defined check for whether to continue.
+// An error rejected by the check is returned wrapped so that errors.Is(err, ErrNonRetryable) holds, as is
+// an error matching io.EOF unless marked with Retryable.
 func RetryWithCustomCheck[T any](fn func() (T, error), maxAttempts int, delay time.Duration, isRetryable func(error) bool) (T, error) {
+	return retryWithCustomCheckBackoff(fn, maxAttempts, ConstantBackoff(delay), isRetryable)
+}
+
+// retryWithCustomCheckBackoff is like RetryWithCustomCheck but waits between attempts according to the
+// backoff strategy.
+func retryWithCustomCheckBackoff[T any](fn func() (T, error), maxAttempts int, backoff BackoffStrategy, isRetryable func(error) bool) (T, error) {
+	maxAttempts = max(maxAttempts, 1)
 	var result T
 	var err error
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
@@ -63,25 +175,90 @@ func RetryWithCustomCheck[T any](fn func() (T, error), maxAttempts int, delay ti
 		}
 
 		// Use the provided function to decide if we should retry.
-		if !isRetryable(err) {
-			return result, err // Return immediately if the error is not retryable.
+		if halts(err, func(err error) bool { return !isRetryable(err) }) {
+			return result, nonRetryable(err) // Return immediately if the error is not retryable.
+		}
+		if attempt == maxAttempts {
+			break
 		}
 
-		logPrintf("Attempt %d/%d failed with an error: %v. Retrying in %v...", attempt, maxAttempts, err, delay)
-		time.Sleep(delay)
+		delay := backoff.Delay(attempt)
+		if logEnabled() {
+			logf("Attempt %d/%d failed with an error: %v. Retrying in %v...", attempt, maxAttempts, err, delay)
+		}
+		if ctxErr := sleepDefault(delay); ctxErr != nil {
+			return result, errors.Join(ctxErr, err)
+		}
 	}
 	return result, err // Last error encountered.
 }
 
 // MustRetryWithNonRetryableErrors attempts to execute the provided function until it succeeds,
 // the maximum number of attempts is reached, or a non-retryable error is encountered.
+// It uses DefaultMaxAttempts and DefaultBackoff for the retry configuration.
 func MustRetryWithNonRetryableErrors[T any](fn func() (T, error), nonRetryableErrors []string) (T, error) {
-	return RetryWithNonRetryableErrors(fn, DefaultMaxAttempts, DefaultDelay, nonRetryableErrors)
+	return RetryWithNonRetryableErrorsBackoff(fn, DefaultMaxAttempts, DefaultBackoff(), nonRetryableErrors)
 }
 
 // RetryWithNonRetryableErrors gracefully handles retry logic for functions that may fail with retryable errors.
 // It supports custom delays and distinguishes between errors that should halt retries.
+// An error halting the retries, as io.EOF does unless marked with Retryable, is returned wrapped so that
+// errors.Is(err, ErrNonRetryable) holds.
 func RetryWithNonRetryableErrors[T any](fn func() (T, error), maxAttempts int, delay time.Duration, nonRetryableErrors []string) (T, error) {
+	return RetryWithNonRetryableErrorsBackoff(fn, maxAttempts, ConstantBackoff(delay), nonRetryableErrors)
+}
+
+// MustRetryWithRetryableErrors attempts to execute the provided function until it succeeds,
+// the maximum number of attempts is reached, or a non-retryable error is encountered.
+// It uses DefaultMaxAttempts and DefaultBackoff for the retry configuration.
+func MustRetryWithRetryableErrors[T any](fn func() (T, error), retryableErrors []string) (T, error) {
+	return RetryWithRetryableErrorsBackoff(fn, DefaultMaxAttempts, DefaultBackoff(), retryableErrors)
+}
+
+// RetryWithRetryableErrors executes a function until it succeeds, the maximum number of attempts is reached,
+// or a non-retryable error is encountered.
+// A non-retryable error, such as io.EOF unless marked with Retryable, is returned wrapped so that
+// errors.Is(err, ErrNonRetryable) holds.
+func RetryWithRetryableErrors[T any](fn func() (T, error), maxAttempts int, delay time.Duration, retryableErrors []string) (T, error) {
+	return RetryWithRetryableErrorsBackoff(fn, maxAttempts, ConstantBackoff(delay), retryableErrors)
+}
+
+// RetryWithRetryableErrorsBackoff is like RetryWithRetryableErrors but waits between attempts according to
+// the backoff strategy, so that transient errors such as timeouts can be retried with growing delays.
+func RetryWithRetryableErrorsBackoff[T any](fn func() (T, error), maxAttempts int, backoff BackoffStrategy, retryableErrors []string) (T, error) {
+	maxAttempts = max(maxAttempts, 1)
+	var result T
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		result, err = fn()
+		if err == nil {
+			return result, nil
+		}
+
+		if attempt == 1 {
+			// Deduplicate once a retry may be needed only, so that a first success costs nothing.
+			retryableErrors = DedupPatterns(retryableErrors)
+		}
+		// Checks if the error is retryable.
+		if halts(err, func(err error) bool { return !ContainsError(err, retryableErrors) }) {
+			return result, nonRetryable(err)
+		}
+
+		delay := backoff.Delay(attempt)
+		if logEnabled() {
+			logf("Attempt %d/%d failed with a retryable error: %v. Retrying in %v...", attempt, maxAttempts, err, delay)
+		}
+		if ctxErr := sleepDefault(delay); ctxErr != nil {
+			return result, errors.Join(ctxErr, err)
+		}
+	}
+	return result, err // Return the last error encountered.
+}
+
+// RetryWithNonRetryableErrorsBackoff is like RetryWithNonRetryableErrors but waits between attempts according
+// to the backoff strategy.
+func RetryWithNonRetryableErrorsBackoff[T any](fn func() (T, error), maxAttempts int, backoff BackoffStrategy, nonRetryableErrors []string) (T, error) {
+	maxAttempts = max(maxAttempts, 1)
 	var result T
 	var err error
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
@@ -90,26 +267,55 @@ func RetryWithNonRetryableErrors[T any](fn func() (T, error), maxAttempts int, d
 			return result, nil
 		}
 
+		if attempt == 1 {
+			// Deduplicate once a retry may be needed only, so that a first success costs nothing.
+			nonRetryableErrors = DedupPatterns(nonRetryableErrors)
+		}
 		// Check if the error is non-retryable.
-		if ContainsError(err, nonRetryableErrors) {
-			return result, err // Return immediately on a non-retryable error.
+		if halts(err, func(err error) bool { return ContainsError(err, nonRetryableErrors) }) {
+			return result, nonRetryable(err) // Return immediately on a non-retryable error.
 		}
 
-		logPrintf("Attempt %d/%d failed with an error: %v. Retrying in %v...", attempt, maxAttempts, err, delay)
-		time.Sleep(delay)
+		delay := backoff.Delay(attempt)
+		if logEnabled() {
+			logf("Attempt %d/%d failed with an error: %v. Retrying in %v...", attempt, maxAttempts, err, delay)
+		}
+		if ctxErr := sleepDefault(delay); ctxErr != nil {
+			return result, errors.Join(ctxErr, err)
+		}
 	}
 	return result, err // Last error encountered.
 }
 
-// MustRetryWithRetryableErrors attempts to execute the provided function until it succeeds,
-// the maximum number of attempts is reached, or a non-retryable error is encountered.
-func MustRetryWithRetryableErrors[T any](fn func() (T, error), retryableErrors []string) (T, error) {
-	return RetryWithRetryableErrors(fn, DefaultMaxAttempts, DefaultDelay, retryableErrors)
+// RetryWithErrorDelays retries the provided function like Retry, but chooses the delay before the next attempt
+// by matching the error message against the keys of delays (substring match), falling back to defaultDelay.
+// When several keys match, the longest one wins so that more specific patterns take precedence.
+func RetryWithErrorDelays[T any](fn func() (T, error), maxAttempts int, defaultDelay time.Duration, delays map[string]time.Duration) (T, error) {
+	maxAttempts = max(maxAttempts, 1)
+	var result T
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		result, err = fn()
+		if err == nil {
+			return result, nil
+		}
+
+		delay := delayForError(err, defaultDelay, delays)
+		if logEnabled() {
+			logf("Attempt %d/%d failed with an error: %v. Retrying in %v...", attempt, maxAttempts, err, delay)
+		}
+		if ctxErr := sleepDefault(delay); ctxErr != nil {
+			return result, errors.Join(ctxErr, err)
+		}
+	}
+	return result, err // Return the last error encountered.
 }
 
-// RetryWithRetryableErrors executes a function until it succeeds, the maximum number of attempts is reached,
-// or a non-retryable error is encountered.
-func RetryWithRetryableErrors[T any](fn func() (T, error), maxAttempts int, delay time.Duration, retryableErrors []string) (T, error) {
+// RetryWithSentinelDelays retries the provided function like Retry, but chooses the delay before the next attempt
+// by checking the error against the sentinel keys of delays with errors.Is, falling back to defaultDelay.
+// Keys should not overlap in the error chain; if several match, which one is used is unspecified.
+func RetryWithSentinelDelays[T any](fn func() (T, error), maxAttempts int, defaultDelay time.Duration, delays map[error]time.Duration) (T, error) {
+	maxAttempts = max(maxAttempts, 1)
 	var result T
 	var err error
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
@@ -118,24 +324,380 @@ func RetryWithRetryableErrors[T any](fn func() (T, error), maxAttempts int, dela
 			return result, nil
 		}
 
-		// Checks if the error is retryable.
-		if !ContainsError(err, retryableErrors) {
+		delay := defaultDelay
+		for target, d := range delays {
+			if errors.Is(err, target) {
+				delay = d
+				break
+			}
+		}
+		if logEnabled() {
+			logf("Attempt %d/%d failed with an error: %v. Retrying in %v...", attempt, maxAttempts, err, delay)
+		}
+		if ctxErr := sleepDefault(delay); ctxErr != nil {
+			return result, errors.Join(ctxErr, err)
+		}
+	}
+	return result, err // Return the last error encountered.
+}
+
+// RetryWhile executes the provided function until keepRetrying returns false for its result and error, up
+// to the maximum number of attempts, pausing with a delay between each try, and returns the last result and
+// error.
+func RetryWhile[T any](fn func() (T, error), maxAttempts int, delay time.Duration, keepRetrying func(T, error) bool) (T, error) {
+	maxAttempts = max(maxAttempts, 1)
+	var result T
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		result, err = fn()
+		if !keepRetrying(result, err) {
 			return result, err
 		}
 
-		logPrintf("Attempt %d/%d failed with a retryable error: %v. Retrying in %v...", attempt, maxAttempts, err, delay)
-		time.Sleep(delay)
+		if logEnabled() {
+			logf("Attempt %d/%d still requires a retry (error: %v). Retrying in %v...", attempt, maxAttempts, err, delay)
+		}
+		if ctxErr := sleepDefault(delay); ctxErr != nil {
+			return result, errors.Join(ctxErr, err)
+		}
+	}
+	return result, err // Return the last result and error encountered.
+}
+
+// RetryUntilStableSuccess executes the provided function until it succeeds requiredConsecutive times in a row,
+// pausing with a delay between each try, to avoid acting on a transient success. Any error resets the streak.
+// It returns the result of the last success once the streak is reached. If the attempts run out before that,
+// it returns the last error encountered, or ErrUnstableSuccess if no attempt failed.
+func RetryUntilStableSuccess[T any](fn func() (T, error), requiredConsecutive int, maxAttempts int, delay time.Duration) (T, error) {
+	maxAttempts = max(maxAttempts, 1)
+	var result T
+	var err, lastErr error
+	streak := 0
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		result, err = fn()
+		if err == nil {
+			streak++
+			if streak >= requiredConsecutive {
+				return result, nil
+			}
+			if logEnabled() {
+				logf("Attempt %d/%d succeeded (%d/%d consecutive). Checking again in %v...", attempt, maxAttempts, streak, requiredConsecutive, delay)
+			}
+		} else {
+			streak, lastErr = 0, err
+			if logEnabled() {
+				logf("Attempt %d/%d failed with an error: %v. Retrying in %v...", attempt, maxAttempts, err, delay)
+			}
+		}
+		if ctxErr := sleepDefault(delay); ctxErr != nil {
+			return result, errors.Join(ctxErr, lastErr)
+		}
+	}
+	if lastErr == nil {
+		lastErr = ErrUnstableSuccess
+	}
+	return result, lastErr // Return the last error encountered.
+}
+
+// RetryWithVerification executes the provided function and then verifies its result, retrying until both
+// succeed or the maximum number of attempts is reached, pausing with a delay between each try.
+// A failing verification counts as a retryable failure, which suits eventually consistent writes confirmed
+// by a read-after-write. On exhaustion it returns the last result and the last error, from whichever step failed.
+func RetryWithVerification[T any](fn func() (T, error), verify func(T) error, maxAttempts int, delay time.Duration) (T, error) {
+	maxAttempts = max(maxAttempts, 1)
+	var result T
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		result, err = fn()
+		if err == nil {
+			if err = verify(result); err == nil {
+				return result, nil
+			}
+			if logEnabled() {
+				logf("Attempt %d/%d failed verification: %v. Retrying in %v...", attempt, maxAttempts, err, delay)
+			}
+		} else if logEnabled() {
+			logf("Attempt %d/%d failed with an error: %v. Retrying in %v...", attempt, maxAttempts, err, delay)
+		}
+		if ctxErr := sleepDefault(delay); ctxErr != nil {
+			return result, errors.Join(ctxErr, err)
+		}
 	}
 	return result, err // Return the last error encountered.
 }
 
+// RetryAfterProbe retries probe, such as a health check, like Retry, and calls fn a single time once the
+// probe succeeds. If it never does, fn is not called and the last probe error is returned.
+func RetryAfterProbe[T any](probe func() error, fn func() (T, error), maxAttempts int, delay time.Duration) (T, error) {
+	_, err := Retry(func() (struct{}, error) {
+		return struct{}{}, probe()
+	}, maxAttempts, delay)
+	if err != nil {
+		var zero T
+		return zero, err // The service never became healthy.
+	}
+	return fn()
+}
+
+// RetryWithCleanup is like Retry but calls cleanup after each failed attempt that is going to be retried,
+// before the delay, so that per-attempt resources such as files or locks are released before the next try.
+// The cleanup is not called after a success nor after the final failed attempt; use WithCleanup and
+// WithFinalCleanup with RetryWithOptions to also clean up after the final attempt.
+func RetryWithCleanup[T any](fn func() (T, error), cleanup func(), maxAttempts int, delay time.Duration) (T, error) {
+	maxAttempts = max(maxAttempts, 1)
+	var result T
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		result, err = fn()
+		if err == nil {
+			return result, nil
+		}
+		if attempt == maxAttempts {
+			break
+		}
+
+		cleanup()
+		if logEnabled() {
+			logf("Attempt %d/%d failed: %v. Retrying in %v...", attempt, maxAttempts, err, delay)
+		}
+		if ctxErr := sleepDefault(delay); ctxErr != nil {
+			return result, errors.Join(ctxErr, err)
+		}
+	}
+	return result, err // Return the last error encountered
+}
+
+// RetryUntilSignal keeps retrying the provided function on any error, pausing delay between attempts, until
+// it succeeds, signal fires or the default context is done, returning the last result and error.
+func RetryUntilSignal[T any](fn func() (T, error), signal <-chan struct{}, delay time.Duration) (T, error) {
+	ctx := DefaultContext()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for attempt := 1; ; attempt++ {
+		result, err := fn()
+		if err == nil {
+			return result, nil
+		}
+
+		if logEnabled() {
+			logf("Attempt %d failed with an error: %v. Retrying in %v...", attempt, err, delay)
+		}
+		timer.Reset(delay)
+		select {
+		case <-signal:
+			return result, err // Given up waiting, return the last error encountered.
+		case <-ctx.Done():
+			return result, errors.Join(ctx.Err(), err)
+		case <-timer.C:
+		}
+	}
+}
+
+// delayForError returns the delay of the longest key of delays contained in the error message,
+// or defaultDelay if none matches.
+func delayForError(err error, defaultDelay time.Duration, delays map[string]time.Duration) time.Duration {
+	delay, matched := defaultDelay, -1
+	msg := err.Error()
+	for pattern, d := range delays {
+		if len(pattern) > matched && strings.Contains(msg, pattern) {
+			delay, matched = d, len(pattern)
+		}
+	}
+	return delay
+}
+
 // ContainsError checks if the error message contains any of the substrings
 // in the list of errors allowed for retrying.
 func ContainsError(err error, listErrors []string) bool {
+	msg := err.Error()
 	for _, strErr := range listErrors {
-		if strings.Contains(err.Error(), strErr) {
+		if strings.Contains(msg, strErr) {
 			return true
 		}
 	}
 	return false
 }
+
+// RetryWithFactory is like Retry but calls factory before each attempt to produce a fresh input for fn,
+// which owns it. A factory error counts as a failed attempt, or stops the retries if it matches
+// ErrNonRetryable.
+func RetryWithFactory[In, Out any](factory func() (In, error), fn func(In) (Out, error), maxAttempts int, delay time.Duration) (Out, error) {
+	maxAttempts = max(maxAttempts, 1)
+	var result Out
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		var input In
+		input, err = factory()
+		if err != nil {
+			if errors.Is(err, ErrNonRetryable) {
+				return result, err
+			}
+		} else if result, err = fn(input); err == nil {
+			return result, nil
+		}
+		if attempt == maxAttempts {
+			break
+		}
+
+		if logEnabled() {
+			logf("Attempt %d/%d failed: %v. Retrying in %v...", attempt, maxAttempts, err, delay)
+		}
+		if ctxErr := sleepDefault(delay); ctxErr != nil {
+			return result, errors.Join(ctxErr, err)
+		}
+	}
+	return result, err // Return the last error encountered
+}
+
+// RetryWithFallback is like Retry but degrades gracefully: when the attempts are exhausted, or an attempt
+// fails with an error marked with Permanent, it calls fallback once with the last error and returns what
+// fallback returns instead, such as a default value with a nil error or a transformed error. fallback is
+// not called when an attempt succeeds. No delay follows the final attempt.
+func RetryWithFallback[T any](fn func() (T, error), fallback func(lastErr error) (T, error), maxAttempts int, delay time.Duration) (T, error) {
+	maxAttempts = max(maxAttempts, 1)
+	var err error
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		var result T
+		result, err = fn()
+		if err == nil {
+			return result, nil
+		}
+		if attempt == maxAttempts || errors.Is(err, ErrNonRetryable) {
+			break
+		}
+
+		if logEnabled() {
+			logf("Attempt %d/%d failed: %v. Retrying in %v...", attempt, maxAttempts, err, delay)
+		}
+		if ctxErr := sleepDefault(delay); ctxErr != nil {
+			err = errors.Join(ctxErr, err)
+			break
+		}
+	}
+	return fallback(err)
+}
+
+// RetryWithMemo is like Retry for multi-step operations whose completed steps need not be redone: each
+// attempt receives a pointer to the result of the previous attempt, nil on the first one, so fn can resume
+// from a partial result instead of restarting. fn owns the interpretation of prev, typically a record of
+// the steps already done; the pointer is only valid during the call. No delay follows the final attempt.
+func RetryWithMemo[T any](fn func(prev *T) (T, error), maxAttempts int, delay time.Duration) (T, error) {
+	maxAttempts = max(maxAttempts, 1)
+	var result T
+	var err error
+	var prev *T
+	for attempt := 1; attempt <= maxAttempts; attempt++ {
+		result, err = fn(prev)
+		if err == nil {
+			return result, nil
+		}
+		if attempt == maxAttempts {
+			break
+		}
+
+		partial := result
+		prev = &partial
+		if logEnabled() {
+			logf("Attempt %d/%d failed: %v. Retrying in %v...", attempt, maxAttempts, err, delay)
+		}
+		if ctxErr := sleepDefault(delay); ctxErr != nil {
+			return result, errors.Join(ctxErr, err)
+		}
+	}
+	return result, err // Return the last error encountered
+}
+
+// RetryMap retries fn for every entry of m independently, like Retry, and returns the results of the entries
+// that succeeded and the last errors of those that did not, keyed like m: each key is in exactly one of the
+// two maps. The entries are processed sequentially, one after the other, in the unspecified iteration order
+// of the map, so fn must not depend on the order; the outcome of an entry does not affect the others.
+func RetryMap[K comparable, V any, Out any](m map[K]V, fn func(K, V) (Out, error), maxAttempts int, delay time.Duration) (map[K]Out, map[K]error) {
+	results := make(map[K]Out, len(m))
+	errs := make(map[K]error)
+	for k, v := range m {
+		out, err := Retry(func() (Out, error) { return fn(k, v) }, maxAttempts, delay)
+		if err != nil {
+			errs[k] = err
+			continue
+		}
+		results[k] = out
+	}
+	return results, errs
+}
+
+// RetryPartial retries the failed items of a bulk operation only, passing fn the inputs still pending, and
+// fn reports the results and the failed items keyed by index in pending. It returns the results keyed by
+// index in inputs and, if inputs are still pending, an error reporting how many, wrapping the last one of
+// fn.
+func RetryPartial[In, Out any](inputs []In, fn func(pending []In) (results map[int]Out, failed []int, err error), maxAttempts int, delay time.Duration) (map[int]Out, error) {
+	maxAttempts = max(maxAttempts, 1)
+	results := make(map[int]Out, len(inputs))
+	pending := make([]int, len(inputs)) // indices in inputs
+	for i := range inputs {
+		pending[i] = i
+	}
+	var err error
+	for attempt := 1; attempt <= maxAttempts && len(pending) > 0; attempt++ {
+		batch := make([]In, len(pending))
+		for i, index := range pending {
+			batch[i] = inputs[index]
+		}
+
+		var attemptResults map[int]Out
+		var failed []int
+		attemptResults, failed, err = fn(batch)
+		var failing []int
+		for i, index := range pending {
+			if out, ok := attemptResults[i]; ok && !slices.Contains(failed, i) {
+				results[index] = out
+				continue
+			}
+			failing = append(failing, index)
+		}
+		pending = failing
+		if len(pending) == 0 || attempt == maxAttempts {
+			break
+		}
+
+		if logEnabled() {
+			logf("Attempt %d/%d left %d input(s) failing: %v. Retrying in %v...", attempt, maxAttempts, len(pending), err, delay)
+		}
+		if ctxErr := sleepDefault(delay); ctxErr != nil {
+			return results, errors.Join(ctxErr, partialError(len(pending), len(inputs), err))
+		}
+	}
+	if len(pending) == 0 {
+		return results, nil
+	}
+	return results, partialError(len(pending), len(inputs), err)
+}
+
+// partialError builds the error of RetryPartial.
+func partialError(pending, total int, err error) error {
+	if err == nil {
+		return fmt.Errorf("%d of %d input(s) still failing", pending, total)
+	}
+	return fmt.Errorf("%d of %d input(s) still failing: %w", pending, total, err)
+}
+
+// ErrServedStale is wrapped by the error of RetryWithStaleCache when it served a cached value.
+var ErrServedStale = errors.New("retryable: served stale value")
+
+// RetryWithStaleCache is like RetryWithFallback with cache as the fallback: it serves the cached value if
+// at most maxStale old, with an error wrapping ErrServedStale and the last error, or otherwise returns the
+// last result and error.
+func RetryWithStaleCache[T any](fn func() (T, error), cache func() (T, time.Time, bool), maxStale time.Duration, maxAttempts int, delay time.Duration) (T, error) {
+	var result T
+	return RetryWithFallback(func() (T, error) {
+		var err error
+		result, err = fn()
+		return result, err
+	}, func(lastErr error) (T, error) {
+		cached, cachedAt, ok := cache()
+		if !ok || time.Since(cachedAt) > maxStale {
+			return result, lastErr
+		}
+		return cached, fmt.Errorf("%w: %w", ErrServedStale, lastErr)
+	}, maxAttempts, delay)
+}
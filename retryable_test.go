@@ -3,6 +3,8 @@ package retryable_test
 import (
 	"errors"
 	"fmt"
+	"io"
+	"log"
 	"strings"
 	"testing"
 	"time"
@@ -111,6 +113,39 @@ func TestRetry(t *testing.T) {
 	}
 }
 
+// TestRetryEOF tests that Retry calls a function returning io.EOF once, unless the error is marked with Retryable.
+func TestRetryEOF(t *testing.T) {
+	attempts := 0
+	_, err := retryable.Retry(func() (int, error) {
+		attempts++
+		return 0, fmt.Errorf("read: %w", io.EOF)
+	}, 3, 1*time.Millisecond)
+	if attempts != 1 || !errors.Is(err, io.EOF) || !errors.Is(err, retryable.ErrNonRetryable) {
+		t.Errorf("Expected a single non-retryable attempt, got %d attempts and %v", attempts, err)
+	}
+
+	attempts = 0
+	_, err = retryable.Retry(func() (int, error) {
+		attempts++
+		return 0, retryable.Retryable(io.EOF)
+	}, 3, 1*time.Millisecond)
+	if attempts != 3 || !errors.Is(err, io.EOF) {
+		t.Errorf("Expected 3 attempts of a marked io.EOF, got %d attempts and %v", attempts, err)
+	}
+}
+
+// TestRetryWithCustomCheckEOF tests that io.EOF stops RetryWithCustomCheck even when the check accepts it.
+func TestRetryWithCustomCheckEOF(t *testing.T) {
+	attempts := 0
+	_, err := retryable.RetryWithCustomCheck(func() (int, error) {
+		attempts++
+		return 0, io.EOF
+	}, 3, 1*time.Millisecond, func(error) bool { return true })
+	if attempts != 1 || !errors.Is(err, retryable.ErrNonRetryable) {
+		t.Errorf("Expected a single non-retryable attempt, got %d attempts and %v", attempts, err)
+	}
+}
+
 func TestContainsError(t *testing.T) {
 	retryableErrors := []string{"temporary", "intermittent"}
 	err := errors.New("this is a temporary issue")
@@ -214,6 +249,33 @@ func TestMustRetryCustomDelayAndAttempts(t *testing.T) {
 	}
 }
 
+// TestSetDefaultBackoff tests that MustRetry and the option-based functions follow the default backoff.
+func TestSetDefaultBackoff(t *testing.T) {
+	defer retryable.SetDefaultBackoff(nil)
+	retryable.SetDefaultBackoff(retryable.ExponentialBackoff(1*time.Millisecond, 2, 0))
+
+	var delays []string
+	retryable.SetLoggerWriter(func(format string, args ...interface{}) {
+		delays = append(delays, fmt.Sprint(args[len(args)-1]))
+	})
+	defer retryable.SetLoggerWriter(log.Printf)
+
+	fn := func() (bool, error) {
+		return false, errors.New("temporary error")
+	}
+	retryable.MustRetry(fn)
+	retryable.RetryWithOptions(fn)
+
+	if got := strings.Join(delays, " "); got != "1ms 2ms 1ms 2ms" {
+		t.Errorf("Expected the exponential default delays, got %s", got)
+	}
+
+	retryable.SetDefaultBackoff(nil)
+	if got := retryable.DefaultBackoff().Delay(3); got != retryable.DefaultDelay {
+		t.Errorf("Expected the default to be restored to DefaultDelay, got %v", got)
+	}
+}
+
 // TestMustRetryWhenFirstAttemptSucceeds tests the MustRetry function when the operation succeeds on the first attempt.
 func TestMustRetryWhenFirstAttemptSucceeds(t *testing.T) {
 	fn := func() (bool, error) {
@@ -383,3 +445,743 @@ func TestRetryWithRetryableErrorsMaxAttemptsReached(t *testing.T) {
 		t.Errorf("Expected to reach max attempts with retryable errors, got %v, attempts: %d", err, attempts)
 	}
 }
+
+// TestRetryWithErrorDelays tests that the delay is chosen by the most specific matching pattern.
+func TestRetryWithErrorDelays(t *testing.T) {
+	var delays []string
+	retryable.SetLoggerWriter(func(format string, args ...interface{}) {
+		delays = append(delays, fmt.Sprint(args[len(args)-1]))
+	})
+	defer retryable.SetLoggerWriter(log.Printf)
+
+	errs := []error{errors.New("rate limit exceeded"), errors.New("connection reset"), errors.New("unknown")}
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		if attempts <= len(errs) {
+			return 0, errs[attempts-1]
+		}
+		return attempts, nil
+	}
+
+	patterns := map[string]time.Duration{
+		"rate limit":          3 * time.Millisecond,
+		"rate limit exceeded": 4 * time.Millisecond,
+		"reset":               2 * time.Millisecond,
+	}
+	result, err := retryable.RetryWithErrorDelays(fn, 5, 1*time.Millisecond, patterns)
+	if err != nil || result != 4 {
+		t.Fatalf("Expected success on the fourth attempt, got %v with error %v", result, err)
+	}
+
+	expected := []string{"4ms", "2ms", "1ms"}
+	if strings.Join(delays, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected delays %v, got %v", expected, delays)
+	}
+}
+
+// TestRetryWithSentinelDelays tests that the delay is chosen with errors.Is on wrapped errors.
+func TestRetryWithSentinelDelays(t *testing.T) {
+	var delays []string
+	retryable.SetLoggerWriter(func(format string, args ...interface{}) {
+		delays = append(delays, fmt.Sprint(args[len(args)-1]))
+	})
+	defer retryable.SetLoggerWriter(log.Printf)
+
+	errRateLimited := errors.New("rate limited")
+	fn := func() (bool, error) {
+		return false, fmt.Errorf("request failed: %w", errRateLimited)
+	}
+
+	_, err := retryable.RetryWithSentinelDelays(fn, 2, 1*time.Millisecond, map[error]time.Duration{errRateLimited: 2 * time.Millisecond})
+	if !errors.Is(err, errRateLimited) {
+		t.Fatalf("Expected the last error to wrap the sentinel, got %v", err)
+	}
+	if strings.Join(delays, ",") != "2ms,2ms" {
+		t.Errorf("Expected sentinel delays, got %v", delays)
+	}
+}
+
+// TestRetryUntilSignal tests that retries stop with the last error once the signal fires.
+func TestRetryUntilSignal(t *testing.T) {
+	signal := make(chan struct{})
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		if attempts == 3 {
+			close(signal)
+		}
+		return attempts, fmt.Errorf("attempt %d failed", attempts)
+	}
+
+	result, err := retryable.RetryUntilSignal(fn, signal, 1*time.Millisecond)
+	if err == nil || err.Error() != "attempt 3 failed" || result != 3 {
+		t.Errorf("Expected the last error once the signal fired, got %v with error %v", result, err)
+	}
+}
+
+// TestRetryUntilSignalSuccess tests that RetryUntilSignal returns as soon as the function succeeds.
+func TestRetryUntilSignalSuccess(t *testing.T) {
+	attempts := 0
+	fn := func() (bool, error) {
+		attempts++
+		if attempts < 2 {
+			return false, errors.New("not ready")
+		}
+		return true, nil
+	}
+
+	result, err := retryable.RetryUntilSignal(fn, make(chan struct{}), 1*time.Millisecond)
+	if err != nil || !result || attempts != 2 {
+		t.Errorf("Expected success on the second attempt, got %v with error %v after %d attempts", result, err, attempts)
+	}
+}
+
+// TestRetryWhile tests that RetryWhile keeps retrying while the predicate asks for it, even without errors.
+func TestRetryWhile(t *testing.T) {
+	statuses := []string{"pending", "pending", "done"}
+	attempts := 0
+	fn := func() (string, error) {
+		attempts++
+		return statuses[attempts-1], nil
+	}
+
+	keepRetrying := func(status string, err error) bool {
+		return err != nil || status != "done"
+	}
+
+	result, err := retryable.RetryWhile(fn, 5, 1*time.Millisecond, keepRetrying)
+	if err != nil || result != "done" || attempts != 3 {
+		t.Errorf("Expected done after 3 attempts, got %q with error %v after %d attempts", result, err, attempts)
+	}
+}
+
+// TestRetryWhileExhausted tests that RetryWhile returns the last result when attempts run out.
+func TestRetryWhileExhausted(t *testing.T) {
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		return attempts, nil
+	}
+
+	result, err := retryable.RetryWhile(fn, 3, 1*time.Millisecond, func(int, error) bool { return true })
+	if err != nil || result != 3 || attempts != 3 {
+		t.Errorf("Expected the last result after 3 attempts, got %v with error %v", result, err)
+	}
+}
+
+// TestRetryUntilStableSuccess tests that an error resets the success streak.
+func TestRetryUntilStableSuccess(t *testing.T) {
+	outcomes := []error{nil, errors.New("flaky"), nil, nil, nil}
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		return attempts, outcomes[attempts-1]
+	}
+
+	result, err := retryable.RetryUntilStableSuccess(fn, 3, 10, 1*time.Millisecond)
+	if err != nil || result != 5 {
+		t.Errorf("Expected a stable success on the fifth attempt, got %v with error %v", result, err)
+	}
+}
+
+// TestRetryUntilStableSuccessExhausted tests the errors returned when stability is never reached.
+func TestRetryUntilStableSuccessExhausted(t *testing.T) {
+	attempts := 0
+	flaky := func() (int, error) {
+		attempts++
+		if attempts%2 == 0 {
+			return 0, errors.New("flaky")
+		}
+		return attempts, nil
+	}
+
+	_, err := retryable.RetryUntilStableSuccess(flaky, 2, 5, 1*time.Millisecond)
+	if err == nil || err.Error() != "flaky" {
+		t.Errorf("Expected the last error, got %v", err)
+	}
+
+	healthy := func() (bool, error) { return true, nil }
+	_, err = retryable.RetryUntilStableSuccess(healthy, 5, 3, 1*time.Millisecond)
+	if !errors.Is(err, retryable.ErrUnstableSuccess) {
+		t.Errorf("Expected ErrUnstableSuccess, got %v", err)
+	}
+}
+
+// TestRetryWithRetryableErrorsBackoff tests that the error-list function waits according to the strategy.
+func TestRetryWithRetryableErrorsBackoff(t *testing.T) {
+	var delays []time.Duration
+	backoff := retryable.BackoffFunc(func(attempt int) time.Duration {
+		delay := time.Duration(attempt) * time.Millisecond
+		delays = append(delays, delay)
+		return delay
+	})
+
+	attempts := 0
+	fn := func() (bool, error) {
+		attempts++
+		if attempts < 3 {
+			return false, errors.New("timeout error")
+		}
+		return true, nil
+	}
+
+	result, err := retryable.RetryWithRetryableErrorsBackoff(fn, 5, backoff, []string{"timeout"})
+	if err != nil || !result {
+		t.Fatalf("Expected success after retries, got %v with error %v", result, err)
+	}
+	if len(delays) != 2 || delays[0] != 1*time.Millisecond || delays[1] != 2*time.Millisecond {
+		t.Errorf("Expected delays [1ms 2ms], got %v", delays)
+	}
+}
+
+// TestRetryWithNonRetryableErrorsBackoff tests that a non-retryable error stops the backoff loop immediately.
+func TestRetryWithNonRetryableErrorsBackoff(t *testing.T) {
+	attempts := 0
+	fn := func() (bool, error) {
+		attempts++
+		return false, errors.New("fatal error")
+	}
+
+	_, err := retryable.RetryWithNonRetryableErrorsBackoff(fn, 5, retryable.ConstantBackoff(1*time.Millisecond), []string{"fatal"})
+	if err == nil || attempts != 1 {
+		t.Errorf("Expected to stop after one attempt, got %d attempts with error %v", attempts, err)
+	}
+}
+
+// TestRetryWithVerification tests that a failing verification is retried like a failing call.
+func TestRetryWithVerification(t *testing.T) {
+	writes, reads := 0, 0
+	fn := func() (int, error) {
+		writes++
+		if writes == 1 {
+			return 0, errors.New("write failed")
+		}
+		return writes, nil
+	}
+	verify := func(version int) error {
+		reads++
+		if reads < 2 {
+			return errors.New("not visible yet")
+		}
+		return nil
+	}
+
+	result, err := retryable.RetryWithVerification(fn, verify, 5, 1*time.Millisecond)
+	if err != nil || result != 3 || reads != 2 {
+		t.Errorf("Expected success on the third write after 2 reads, got %v with error %v after %d reads", result, err, reads)
+	}
+}
+
+// TestRetryWithVerificationExhausted tests that the last verification error is returned on exhaustion.
+func TestRetryWithVerificationExhausted(t *testing.T) {
+	fn := func() (bool, error) { return true, nil }
+	verify := func(bool) error { return errors.New("not visible yet") }
+
+	_, err := retryable.RetryWithVerification(fn, verify, 3, 1*time.Millisecond)
+	if err == nil || err.Error() != "not visible yet" {
+		t.Errorf("Expected the verification error, got %v", err)
+	}
+}
+
+// TestDisableLogging tests that no message reaches the writer while logging is disabled.
+func TestDisableLogging(t *testing.T) {
+	var messages int
+	retryable.SetLoggerWriter(func(string, ...interface{}) { messages++ })
+	defer retryable.SetLoggerWriter(log.Printf)
+
+	fn := func() (bool, error) {
+		return false, errors.New("error")
+	}
+
+	retryable.DisableLogging()
+	retryable.Retry(fn, 3, 1*time.Millisecond)
+	if messages != 0 {
+		t.Errorf("Expected no log messages while disabled, got %d", messages)
+	}
+
+	retryable.EnableLogging()
+	retryable.Retry(fn, 3, 1*time.Millisecond)
+	if messages != 2 {
+		t.Errorf("Expected 2 log messages once enabled again, got %d", messages)
+	}
+}
+
+// TestRetryWithSleeper tests that the provided sleeper is used instead of time.Sleep.
+func TestRetryWithSleeper(t *testing.T) {
+	var slept []time.Duration
+	sleeper := func(d time.Duration) { slept = append(slept, d) }
+
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, errors.New("temporary error")
+		}
+		return attempts, nil
+	}
+
+	start := time.Now()
+	result, err := retryable.RetryWithSleeper(fn, 5, 1*time.Hour, sleeper)
+	if err != nil || result != 3 {
+		t.Fatalf("Expected success on the third attempt, got %v with error %v", result, err)
+	}
+	if len(slept) != 2 || slept[0] != 1*time.Hour || time.Since(start) > 1*time.Second {
+		t.Errorf("Expected two recorded sleeps of 1h without waiting, got %v", slept)
+	}
+}
+
+// TestMustRetryWithCustomCheckUsesLoggerWriter tests that MustRetryWithCustomCheck logs through the custom logger.
+func TestMustRetryWithCustomCheckUsesLoggerWriter(t *testing.T) {
+	oldMaxAttempts, oldDelay := retryable.DefaultMaxAttempts, retryable.DefaultDelay
+	defer func() {
+		retryable.DefaultMaxAttempts, retryable.DefaultDelay = oldMaxAttempts, oldDelay
+	}()
+	retryable.DefaultMaxAttempts, retryable.DefaultDelay = 3, 1*time.Millisecond
+
+	var logOutput []string
+	retryable.SetLoggerWriter(func(format string, args ...interface{}) {
+		logOutput = append(logOutput, fmt.Sprintf(format, args...))
+	})
+	defer retryable.SetLoggerWriter(log.Printf)
+
+	fn := func() (bool, error) {
+		return false, errors.New("retryable error")
+	}
+	retryable.MustRetryWithCustomCheck(fn, func(error) bool { return true })
+
+	if len(logOutput) != 2 || !strings.HasPrefix(logOutput[0], "Attempt 1/3 failed") {
+		t.Errorf("Expected 2 messages through the custom logger, got %q", logOutput)
+	}
+}
+
+// TestRetryAfterProbe tests that the function runs once after the probe becomes healthy.
+func TestRetryAfterProbe(t *testing.T) {
+	probes, calls := 0, 0
+	probe := func() error {
+		probes++
+		if probes < 3 {
+			return errors.New("cold")
+		}
+		return nil
+	}
+	fn := func() (string, error) {
+		calls++
+		return "warm", nil
+	}
+
+	result, err := retryable.RetryAfterProbe(probe, fn, 5, 1*time.Millisecond)
+	if err != nil || result != "warm" || probes != 3 || calls != 1 {
+		t.Errorf("Expected 3 probes and 1 call, got %d probes and %d calls with %q and error %v", probes, calls, result, err)
+	}
+}
+
+// TestRetryAfterProbeUnhealthy tests that the function is never called when the probe keeps failing.
+func TestRetryAfterProbeUnhealthy(t *testing.T) {
+	calls := 0
+	probe := func() error { return errors.New("down") }
+	fn := func() (string, error) {
+		calls++
+		return "", nil
+	}
+
+	_, err := retryable.RetryAfterProbe(probe, fn, 3, 1*time.Millisecond)
+	if err == nil || err.Error() != "down" || calls != 0 {
+		t.Errorf("Expected the probe error without calling the function, got %v after %d calls", err, calls)
+	}
+}
+
+// TestRetryAfterProbeNoFinalDelay tests that no delay follows the final failed probe.
+func TestRetryAfterProbeNoFinalDelay(t *testing.T) {
+	probe := func() error { return errors.New("down") }
+	fn := func() (string, error) { return "", nil }
+
+	start := time.Now()
+	retryable.RetryAfterProbe(probe, fn, 2, 50*time.Millisecond)
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed >= 100*time.Millisecond {
+		t.Errorf("Expected a single delay of 50ms between the probes, took %v", elapsed)
+	}
+}
+
+// TestRetryWithCleanup tests that cleanup runs between failed attempts only.
+func TestRetryWithCleanup(t *testing.T) {
+	attempts, cleanups := 0, 0
+	fn := func() (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, errors.New("lock busy")
+		}
+		return attempts, nil
+	}
+
+	_, err := retryable.RetryWithCleanup(fn, func() { cleanups++ }, 5, 1*time.Millisecond)
+	if err != nil || cleanups != 2 {
+		t.Errorf("Expected 2 cleanups before the successful attempt, got %d with error %v", cleanups, err)
+	}
+
+	cleanups = 0
+	failing := func() (int, error) { return 0, errors.New("lock busy") }
+	retryable.RetryWithCleanup(failing, func() { cleanups++ }, 3, 1*time.Millisecond)
+	if cleanups != 2 {
+		t.Errorf("Expected no cleanup after the final attempt, got %d cleanups for 3 attempts", cleanups)
+	}
+}
+
+// TestNonRetryableSentinelListBased tests that the list-based functions mark the error that halted the retries.
+func TestNonRetryableSentinelListBased(t *testing.T) {
+	errFatal := errors.New("fatal error")
+	fn := func() (bool, error) {
+		return false, errFatal
+	}
+
+	_, err := retryable.RetryWithNonRetryableErrors(fn, 3, 1*time.Millisecond, []string{"fatal"})
+	if !errors.Is(err, retryable.ErrNonRetryable) || !errors.Is(err, errFatal) || err.Error() != "fatal error" {
+		t.Errorf("Expected a non-retryable error unwrapping to the original one, got %v", err)
+	}
+
+	_, err = retryable.RetryWithRetryableErrors(fn, 3, 1*time.Millisecond, []string{"timeout"})
+	if !errors.Is(err, retryable.ErrNonRetryable) || !errors.Is(err, errFatal) {
+		t.Errorf("Expected a non-retryable error unwrapping to the original one, got %v", err)
+	}
+}
+
+// TestNonRetryableSentinelCustomCheck tests that the custom-check function marks the error that halted the retries, but not exhaustion.
+func TestNonRetryableSentinelCustomCheck(t *testing.T) {
+	errFatal := errors.New("fatal error")
+	fn := func() (bool, error) {
+		return false, errFatal
+	}
+
+	_, err := retryable.RetryWithCustomCheck(fn, 3, 1*time.Millisecond, func(error) bool { return false })
+	if !errors.Is(err, retryable.ErrNonRetryable) || !errors.Is(err, errFatal) {
+		t.Errorf("Expected a non-retryable error unwrapping to the original one, got %v", err)
+	}
+
+	_, err = retryable.RetryWithCustomCheck(fn, 3, 1*time.Millisecond, func(error) bool { return true })
+	if errors.Is(err, retryable.ErrNonRetryable) || err != errFatal {
+		t.Errorf("Expected the plain last error on exhaustion, got %v", err)
+	}
+}
+
+// TestRetryWithFactory tests that every attempt gets a fresh input and that factory failures are retried.
+func TestRetryWithFactory(t *testing.T) {
+	built := 0
+	factory := func() (*strings.Reader, error) {
+		built++
+		if built == 1 {
+			return nil, errors.New("cannot open")
+		}
+		return strings.NewReader("payload"), nil
+	}
+	calls := 0
+	fn := func(r *strings.Reader) (string, error) {
+		calls++
+		var b strings.Builder
+		if _, err := r.WriteTo(&b); err != nil {
+			return "", err
+		}
+		if calls < 2 {
+			return "", errors.New("upload interrupted")
+		}
+		return b.String(), nil
+	}
+
+	result, err := retryable.RetryWithFactory(factory, fn, 5, 1*time.Millisecond)
+	if err != nil || result != "payload" {
+		t.Errorf("Expected the full payload, got %q with error %v", result, err)
+	}
+	if built != 3 || calls != 2 {
+		t.Errorf("Expected 3 inputs built and 2 calls, got %d and %d", built, calls)
+	}
+}
+
+// TestRetryWithFactoryNonRetryable tests that a factory error matching ErrNonRetryable stops the retries.
+func TestRetryWithFactoryNonRetryable(t *testing.T) {
+	built := 0
+	factory := func() (int, error) {
+		built++
+		return 0, fmt.Errorf("%w: missing file", retryable.ErrNonRetryable)
+	}
+	fn := func(int) (int, error) {
+		t.Error("Expected fn not to be called")
+		return 0, nil
+	}
+
+	_, err := retryable.RetryWithFactory(factory, fn, 5, 1*time.Millisecond)
+	if !errors.Is(err, retryable.ErrNonRetryable) || built != 1 {
+		t.Errorf("Expected a single non-retryable factory error, got %v after %d attempts", err, built)
+	}
+}
+
+// TestRetryWithFallback tests that the fallback is called once after the attempts are exhausted, and never on success.
+func TestRetryWithFallback(t *testing.T) {
+	attempts, fallbacks := 0, 0
+	fallback := func(lastErr error) (string, error) {
+		fallbacks++
+		if lastErr.Error() != "error 3" {
+			t.Errorf("Expected the last error, got %v", lastErr)
+		}
+		return "cached", nil
+	}
+	fn := func() (string, error) {
+		attempts++
+		return "", fmt.Errorf("error %d", attempts)
+	}
+
+	result, err := retryable.RetryWithFallback(fn, fallback, 3, 1*time.Millisecond)
+	if err != nil || result != "cached" || attempts != 3 || fallbacks != 1 {
+		t.Errorf("Expected the fallback value after 3 attempts, got %q, %v, %d attempts and %d fallbacks", result, err, attempts, fallbacks)
+	}
+
+	result, err = retryable.RetryWithFallback(func() (string, error) { return "fresh", nil }, fallback, 3, 1*time.Millisecond)
+	if err != nil || result != "fresh" || fallbacks != 1 {
+		t.Errorf("Expected no fallback on success, got %q, %v and %d fallbacks", result, err, fallbacks)
+	}
+}
+
+// TestRetryWithFallbackPermanent tests that a permanent error skips the remaining attempts and reaches the fallback.
+func TestRetryWithFallbackPermanent(t *testing.T) {
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		return 0, retryable.Permanent(errors.New("not found"))
+	}
+	_, err := retryable.RetryWithFallback(fn, func(lastErr error) (int, error) {
+		return 0, fmt.Errorf("lookup failed: %w", lastErr)
+	}, 3, 1*time.Millisecond)
+
+	if attempts != 1 || err.Error() != "lookup failed: not found" {
+		t.Errorf("Expected the transformed error after 1 attempt, got %v after %d attempts", err, attempts)
+	}
+}
+
+// TestRetryWithFallbackNoAttempts tests that fn is still called once when maxAttempts is below 1.
+func TestRetryWithFallbackNoAttempts(t *testing.T) {
+	attempts := 0
+	value, err := retryable.RetryWithFallback(func() (int, error) {
+		attempts++
+		return 0, errors.New("down")
+	}, func(lastErr error) (int, error) {
+		if lastErr == nil {
+			t.Error("Expected the fallback to get the error of fn")
+		}
+		return 7, nil
+	}, 0, 1*time.Millisecond)
+
+	if attempts != 1 || value != 7 || err != nil {
+		t.Errorf("Expected the fallback value after 1 attempt, got %v, %v after %d attempts", value, err, attempts)
+	}
+}
+
+// ExampleRetryWithMemo shows a multi-step operation resuming from the steps completed by the previous attempt.
+func ExampleRetryWithMemo() {
+	retryable.DisableLogging()
+	defer retryable.EnableLogging()
+
+	steps := []string{"download", "verify", "install"}
+	failures := map[string]int{"verify": 1}
+	result, err := retryable.RetryWithMemo(func(prev *[]string) ([]string, error) {
+		var done []string
+		if prev != nil {
+			done = *prev
+		}
+		for _, step := range steps[len(done):] {
+			if failures[step] > 0 {
+				failures[step]--
+				fmt.Println("failed:", step)
+				return done, errors.New(step + " failed")
+			}
+			fmt.Println("done:", step)
+			done = append(done, step)
+		}
+		return done, nil
+	}, 3, 1*time.Millisecond)
+
+	fmt.Println(result, err)
+	// Output:
+	// done: download
+	// failed: verify
+	// done: verify
+	// done: install
+	// [download verify install] <nil>
+}
+
+// TestRetryMap tests that each entry is retried independently and reported under its own key.
+func TestRetryMap(t *testing.T) {
+	shards := map[string]int{"a": 0, "b": 2, "c": 5}
+	calls := map[string]int{}
+	results, errs := retryable.RetryMap(shards, func(shard string, failures int) (string, error) {
+		calls[shard]++
+		if calls[shard] <= failures {
+			return "", fmt.Errorf("shard %s unavailable", shard)
+		}
+		return "written " + shard, nil
+	}, 3, 1*time.Millisecond)
+
+	if len(results) != 2 || results["a"] != "written a" || results["b"] != "written b" {
+		t.Errorf("Unexpected results %v", results)
+	}
+	if len(errs) != 1 || errs["c"] == nil || errs["c"].Error() != "shard c unavailable" {
+		t.Errorf("Unexpected errors %v", errs)
+	}
+	if calls["a"] != 1 || calls["b"] != 3 || calls["c"] != 3 {
+		t.Errorf("Expected the entries to be retried independently, got %v", calls)
+	}
+}
+
+// TestRetryMapNoFinalDelay tests that no delay follows the final attempt of a failing entry.
+func TestRetryMapNoFinalDelay(t *testing.T) {
+	start := time.Now()
+	retryable.RetryMap(map[string]int{"a": 0, "b": 0}, func(string, int) (int, error) {
+		return 0, errors.New("unavailable")
+	}, 2, 50*time.Millisecond)
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond || elapsed >= 150*time.Millisecond {
+		t.Errorf("Expected a single delay of 50ms per entry, took %v", elapsed)
+	}
+}
+
+// TestRetryPartial tests that only the failed items are passed to the next attempt and the results are assembled by input index.
+func TestRetryPartial(t *testing.T) {
+	inputs := []string{"a", "b", "c", "d"}
+	failures := map[string]int{"b": 1, "d": 5}
+	var batches [][]string
+	results, err := retryable.RetryPartial(inputs, func(pending []string) (map[int]string, []int, error) {
+		batches = append(batches, pending)
+		results := map[int]string{}
+		var failed []int
+		for i, item := range pending {
+			if failures[item] > 0 {
+				failures[item]--
+				failed = append(failed, i)
+				continue
+			}
+			results[i] = strings.ToUpper(item)
+		}
+		return results, failed, errors.New("partial failure")
+	}, 3, 1*time.Millisecond)
+
+	if fmt.Sprint(batches) != "[[a b c d] [b d] [d]]" {
+		t.Errorf("Expected the failed items only to be retried, got %v", batches)
+	}
+	if len(results) != 3 || results[0] != "A" || results[1] != "B" || results[2] != "C" {
+		t.Errorf("Unexpected results %v", results)
+	}
+	if err == nil || err.Error() != "1 of 4 input(s) still failing: partial failure" {
+		t.Errorf("Expected an error for the remaining input, got %v", err)
+	}
+}
+
+// TestRetryPartialFailedWithResult tests that an input reported as failed stays pending even with a result.
+func TestRetryPartialFailedWithResult(t *testing.T) {
+	calls := 0
+	results, err := retryable.RetryPartial([]string{"a", "b"}, func(pending []string) (map[int]string, []int, error) {
+		calls++
+		results := map[int]string{}
+		for i, item := range pending {
+			results[i] = strings.ToUpper(item) + "?"
+		}
+		return results, []int{len(pending) - 1}, errors.New("partial failure")
+	}, 2, 1*time.Millisecond)
+
+	if calls != 2 || fmt.Sprint(results) != "map[0:A?]" {
+		t.Errorf("Expected the failed input to be retried and left out, got %v after %d calls", results, calls)
+	}
+	if err == nil || err.Error() != "1 of 2 input(s) still failing: partial failure" {
+		t.Errorf("Expected an error for the failed input, got %v", err)
+	}
+}
+
+// TestRetryWithStaleCache tests that a fresh enough cached value is served when the retries give up, and a too stale one is not.
+func TestRetryWithStaleCache(t *testing.T) {
+	fail := func() (string, error) { return "", errors.New("origin down") }
+	cachedAt := time.Now().Add(-30 * time.Second)
+	cache := func() (string, time.Time, bool) { return "cached", cachedAt, true }
+
+	value, err := retryable.RetryWithStaleCache(fail, cache, 1*time.Minute, 2, 1*time.Millisecond)
+	if value != "cached" || !errors.Is(err, retryable.ErrServedStale) || !strings.Contains(err.Error(), "origin down") {
+		t.Errorf("Expected the cached value to be served, got %q, %v", value, err)
+	}
+
+	value, err = retryable.RetryWithStaleCache(fail, cache, 10*time.Second, 2, 1*time.Millisecond)
+	if value != "" || err == nil || errors.Is(err, retryable.ErrServedStale) {
+		t.Errorf("Expected the too stale value not to be served, got %q, %v", value, err)
+	}
+
+	value, err = retryable.RetryWithStaleCache(func() (string, error) { return "fresh", nil }, func() (string, time.Time, bool) {
+		t.Error("Expected the cache not to be consulted after a success")
+		return "", time.Time{}, false
+	}, 1*time.Minute, 2, 1*time.Millisecond)
+	if value != "fresh" || err != nil {
+		t.Errorf("Expected the fresh value, got %q, %v", value, err)
+	}
+}
+
+// BenchmarkRetryLogging compares the allocations of a call retried twice with logging disabled and enabled.
+// The messages go to a no-op writer while enabled, so the difference is the cost of formatting them.
+func BenchmarkRetryLogging(b *testing.B) {
+	errFailed := errors.New("failed")
+	fn := func() func() (int, error) {
+		attempt := 0
+		return func() (int, error) {
+			attempt++
+			if attempt < 3 {
+				return 0, errFailed
+			}
+			return attempt, nil
+		}
+	}
+	retryable.SetLoggerWriter(func(format string, args ...interface{}) { _ = fmt.Sprintf(format, args...) })
+	b.Cleanup(func() { retryable.SetLoggerWriter(log.Printf) })
+
+	for _, enabled := range []bool{false, true} {
+		name := "disabled"
+		if enabled {
+			name = "enabled"
+		}
+		b.Run(name, func(b *testing.B) {
+			if !enabled {
+				retryable.DisableLogging()
+				defer retryable.EnableLogging()
+			}
+			b.Run("Retry", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					retryable.Retry(fn(), 3, 0)
+				}
+			})
+			b.Run("RetryWithOptions", func(b *testing.B) {
+				b.ReportAllocs()
+				for i := 0; i < b.N; i++ {
+					retryable.RetryWithOptions(fn(), retryable.WithMaxAttempts(3), retryable.WithConstantBackoff(0))
+				}
+			})
+		})
+	}
+}
+
+// TestRetryWithRetryableErrorsSuccessAllocs tests that a first attempt succeeding costs no allocation,
+// however long the pattern list.
+func TestRetryWithRetryableErrorsSuccessAllocs(t *testing.T) {
+	patterns := []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j"}
+	fn := func() (int, error) { return 1, nil }
+	allocs := testing.AllocsPerRun(100, func() {
+		retryable.RetryWithRetryableErrors(fn, 3, 0, patterns)
+		retryable.RetryWithNonRetryableErrors(fn, 3, 0, patterns)
+	})
+	if allocs != 0 {
+		t.Errorf("Expected no allocation on success, got %v", allocs)
+	}
+}
+
+// TestRetryZeroMaxAttempts tests that Retry calls the function once when maxAttempts is below 1.
+func TestRetryZeroMaxAttempts(t *testing.T) {
+	attempts := 0
+	errBoom := errors.New("boom")
+	_, err := retryable.Retry(func() (int, error) {
+		attempts++
+		return 0, errBoom
+	}, 0, time.Millisecond)
+	if err != errBoom || attempts != 1 {
+		t.Errorf("Expected the error of a single attempt, got %v after %d attempts", err, attempts)
+	}
+}
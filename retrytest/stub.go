@@ -0,0 +1,141 @@
+// Package retrytest helps test code that uses the retryable package, by running retry policies
+// synchronously, without sleeping, while recording every attempt and scripting their outcomes.
+package retrytest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// Attempt records an attempt made through a Stub.
+type Attempt struct {
+	// Call is the 1-based number of the call of Do the attempt belongs to.
+	Call int
+	// Number is the 1-based number of the attempt within its call.
+	Number int
+	// Err is the error of the attempt, nil on success.
+	Err error
+	// Scripted reports whether the outcome came from Script rather than from the function.
+	Scripted bool
+}
+
+// Stub is a deterministic stand-in for a *retryable.Retrier, for the code under test that depends on an
+// interface with its Do method. It applies the retry policy of its options with the same semantics as a
+// Retrier, but every wait returns at once, so tests run without real timing. It is safe for concurrent use.
+type Stub struct {
+	retrier *retryable.Retrier
+	clock   *clock
+
+	mu       sync.Mutex
+	script   []error
+	calls    int
+	attempts []Attempt
+}
+
+// NewStub returns a Stub applying the retry policy of the options. A Clock given with retryable.WithClock
+// is replaced by the clock of the Stub.
+func NewStub(opts ...retryable.Option) *Stub {
+	c := &clock{now: time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)}
+	return &Stub{
+		retrier: retryable.NewRetrier(append(opts[:len(opts):len(opts)], retryable.WithClock(c))...),
+		clock:   c,
+	}
+}
+
+// Script queues the outcomes of the next attempts, across calls of Do, in order: an attempt with a
+// non-nil scripted error fails with it without calling the function, and one with a nil entry calls the
+// function as usual. Once the script is used up, every attempt calls the function.
+func (s *Stub) Script(outcomes ...error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.script = append(s.script, outcomes...)
+}
+
+// Do runs the function with the retry policy of the Stub, like retryable.Retrier.Do, recording each
+// attempt and never sleeping.
+func (s *Stub) Do(ctx context.Context, fn func(context.Context) error) error {
+	s.mu.Lock()
+	s.calls++
+	call := s.calls
+	s.mu.Unlock()
+
+	number := 0
+	return s.retrier.Do(ctx, func(ctx context.Context) error {
+		number++
+		scripted, err := s.next()
+		if !scripted {
+			err = fn(ctx)
+		}
+		s.mu.Lock()
+		s.attempts = append(s.attempts, Attempt{Call: call, Number: number, Err: err, Scripted: scripted})
+		s.mu.Unlock()
+		return err
+	})
+}
+
+// next pops the next scripted outcome, reporting whether it replaces the call of the function.
+func (s *Stub) next() (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.script) == 0 {
+		return false, nil
+	}
+	err := s.script[0]
+	s.script = s.script[1:]
+	return err != nil, err
+}
+
+// Calls returns the number of calls of Do so far.
+func (s *Stub) Calls() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls
+}
+
+// Attempts returns the attempts made so far, in order.
+func (s *Stub) Attempts() []Attempt {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Attempt(nil), s.attempts...)
+}
+
+// Delays returns the delays the policy waited between attempts so far, in order, though no time passed.
+func (s *Stub) Delays() []time.Duration {
+	return s.clock.delays()
+}
+
+// clock is a retryable.Clock whose time only moves when sleeping, without waiting.
+type clock struct {
+	mu    sync.Mutex
+	now   time.Time
+	slept []time.Duration
+}
+
+// Now implements retryable.Clock.
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Sleep implements retryable.Clock, recording the delay and advancing the time at once.
+func (c *clock) Sleep(ctx context.Context, d time.Duration) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	c.slept = append(c.slept, d)
+	return nil
+}
+
+// delays returns the recorded delays.
+func (c *clock) delays() []time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]time.Duration(nil), c.slept...)
+}
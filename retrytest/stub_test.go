@@ -0,0 +1,70 @@
+package retrytest_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+	"github.com/raniellyferreira/go-retryable/retrytest"
+)
+
+// doer is the dependency of the code under test, satisfied by both *retryable.Retrier and *retrytest.Stub.
+type doer interface {
+	Do(ctx context.Context, fn func(context.Context) error) error
+}
+
+// fetchProfile is code under test that retries through its dependency.
+func fetchProfile(ctx context.Context, r doer, fetch func() (string, error)) (string, error) {
+	var profile string
+	err := r.Do(ctx, func(context.Context) error {
+		var err error
+		profile, err = fetch()
+		return err
+	})
+	return profile, err
+}
+
+// ExampleStub shows how to test code that retries, scripting a transient failure without any real delay.
+func ExampleStub() {
+	stub := retrytest.NewStub(retryable.WithMaxAttempts(3), retryable.WithExponentialBackoff(1*time.Second, 2, 0))
+	stub.Script(errors.New("connection reset"))
+
+	profile, err := fetchProfile(context.Background(), stub, func() (string, error) {
+		return "alice", nil
+	})
+
+	fmt.Println(profile, err)
+	for _, attempt := range stub.Attempts() {
+		fmt.Printf("attempt %d: %v (scripted: %v)\n", attempt.Number, attempt.Err, attempt.Scripted)
+	}
+	fmt.Println("delays:", stub.Delays())
+	// Output:
+	// alice <nil>
+	// attempt 1: connection reset (scripted: true)
+	// attempt 2: <nil> (scripted: false)
+	// delays: [1s]
+}
+
+// TestStubExhausted tests that the policy of the stub gives up like a Retrier, recording every attempt and delay.
+func TestStubExhausted(t *testing.T) {
+	retryable.DisableLogging()
+	defer retryable.EnableLogging()
+
+	stub := retrytest.NewStub(retryable.WithMaxAttempts(3), retryable.WithExponentialBackoff(1*time.Minute, 2, 0))
+	errDown := errors.New("down")
+	start := time.Now()
+	err := stub.Do(context.Background(), func(context.Context) error { return errDown })
+
+	if !errors.Is(err, retryable.ErrMaxAttemptsReached) || !errors.Is(err, errDown) {
+		t.Errorf("Expected the attempts to be exhausted, got %v", err)
+	}
+	if len(stub.Attempts()) != 3 || stub.Calls() != 1 {
+		t.Errorf("Expected 3 attempts in 1 call, got %+v", stub.Attempts())
+	}
+	if fmt.Sprint(stub.Delays()) != "[1m0s 2m0s]" || time.Since(start) > 1*time.Second {
+		t.Errorf("Expected recorded delays of 1m and 2m without waiting, got %v after %v", stub.Delays(), time.Since(start))
+	}
+}
@@ -0,0 +1,19 @@
+package retryable
+
+// SharedGroup deduplicates concurrent calls sharing a key, running the function once for all of them.
+// *singleflight.Group of golang.org/x/sync/singleflight satisfies it, so the package does not depend on it.
+type SharedGroup interface {
+	Do(key string, fn func() (any, error)) (v any, err error, shared bool)
+}
+
+// RetryShared runs the retry loop of RetryWithOptions through the group, so that concurrent calls for the
+// same key share a single loop and all get its result, instead of piling up attempts against the same
+// resource, e.g. when a cache entry expires under load. A call made after the loop for a key finished
+// starts a new one. The options of the call that starts the loop are the ones applied.
+func RetryShared[T any](group SharedGroup, key string, fn func() (T, error), opts ...Option) (T, error) {
+	v, err, _ := group.Do(key, func() (any, error) {
+		return RetryWithOptions(fn, opts...)
+	})
+	value, _ := v.(T)
+	return value, err
+}
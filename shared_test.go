@@ -0,0 +1,83 @@
+package retryable_test
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// sharedGroup is a minimal SharedGroup, like singleflight.Group.
+type sharedGroup struct {
+	mu    sync.Mutex
+	calls map[string]*sharedCall
+}
+
+// sharedCall is a call in flight of a sharedGroup.
+type sharedCall struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+func (g *sharedGroup) Do(key string, fn func() (any, error)) (any, error, bool) {
+	g.mu.Lock()
+	if call, ok := g.calls[key]; ok {
+		g.mu.Unlock()
+		<-call.done
+		return call.value, call.err, true
+	}
+	call := &sharedCall{done: make(chan struct{})}
+	g.calls[key] = call
+	g.mu.Unlock()
+
+	call.value, call.err = fn()
+	g.mu.Lock()
+	delete(g.calls, key)
+	g.mu.Unlock()
+	close(call.done)
+	return call.value, call.err, false
+}
+
+// TestRetryShared tests that concurrent calls for the same key share a single retry loop and its result.
+func TestRetryShared(t *testing.T) {
+	group := &sharedGroup{calls: make(map[string]*sharedCall)}
+	var attempts atomic.Int32
+	release := make(chan struct{})
+	fn := func() (int, error) {
+		<-release
+		if attempts.Add(1) < 2 {
+			return 0, errors.New("cache miss")
+		}
+		return 42, nil
+	}
+
+	var wg sync.WaitGroup
+	results := make([]int, 10)
+	for i := range results {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			value, err := retryable.RetryShared(group, "user:1", fn, retryable.WithDelay(1*time.Millisecond))
+			if err != nil {
+				t.Errorf("Expected no error, got %v", err)
+			}
+			results[i] = value
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if attempts.Load() != 2 {
+		t.Errorf("Expected a single loop of 2 attempts, got %d attempts", attempts.Load())
+	}
+	for i, value := range results {
+		if value != 42 {
+			t.Errorf("Expected caller %d to get 42, got %d", i, value)
+		}
+	}
+}
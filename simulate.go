@@ -0,0 +1,79 @@
+package retryable
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+)
+
+// errSimulated is the error of the failed attempts of SimulateDistribution.
+var errSimulated = errors.New("retryable: simulated failure")
+
+// DistributionReport summarizes the runs of SimulateDistribution.
+type DistributionReport struct {
+	// Runs is the number of simulated calls.
+	Runs int
+	// SuccessRate is the fraction of the runs that ended with a success, within [0, 1].
+	SuccessRate float64
+	// Attempts maps a number of attempts to the number of runs that made that many.
+	Attempts map[int]int
+	// Delays holds the total delay of every run, in ascending order.
+	Delays []time.Duration
+}
+
+// DelayPercentile returns the given percentile, between 0 and 1, of the total delays of the runs,
+// e.g. 0.99 for the p99 retry latency, or zero if there were no runs.
+func (r DistributionReport) DelayPercentile(p float64) time.Duration {
+	if len(r.Delays) == 0 {
+		return 0
+	}
+	return percentileOf(r.Delays, p)
+}
+
+// SimulateDistribution runs the policy of the options runs times against an operation failing with
+// probability failProbability, with a simulated clock, and reports the distribution of the attempts and the
+// total delays. WithRandSource makes the report reproducible.
+func SimulateDistribution(opts []Option, failProbability float64, runs int) DistributionReport {
+	report := DistributionReport{Runs: runs, Attempts: make(map[int]int)}
+	successes := 0
+	for i := 0; i < runs; i++ {
+		c := newConfig(opts)
+		c.clock = &simulatedClock{}
+		c.quiet = true
+		res := retryLoop(context.Background(), func(context.Context) (struct{}, error) {
+			if c.randFloat() < failProbability {
+				return struct{}{}, errSimulated
+			}
+			return struct{}{}, nil
+		}, c)
+
+		if res.Err == nil {
+			successes++
+		}
+		report.Attempts[res.Attempts]++
+		report.Delays = append(report.Delays, res.Elapsed)
+	}
+
+	sort.Slice(report.Delays, func(i, j int) bool { return report.Delays[i] < report.Delays[j] })
+	if runs > 0 {
+		report.SuccessRate = float64(successes) / float64(runs)
+	}
+	return report
+}
+
+// simulatedClock is a Clock whose time only moves when sleeping, without waiting.
+type simulatedClock struct {
+	now time.Time
+}
+
+// Now returns the simulated time.
+func (c *simulatedClock) Now() time.Time {
+	return c.now
+}
+
+// Sleep advances the simulated time by d immediately.
+func (c *simulatedClock) Sleep(_ context.Context, d time.Duration) error {
+	c.now = c.now.Add(d)
+	return nil
+}
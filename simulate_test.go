@@ -0,0 +1,53 @@
+package retryable_test
+
+import (
+	"math/rand/v2"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestSimulateDistribution tests the report of a deterministic policy against always and never failing operations.
+func TestSimulateDistribution(t *testing.T) {
+	opts := []retryable.Option{retryable.WithMaxAttempts(4), retryable.WithExponentialBackoff(1*time.Second, 2, 0)}
+
+	failing := retryable.SimulateDistribution(opts, 1, 50)
+	if failing.Runs != 50 || failing.SuccessRate != 0 || failing.Attempts[4] != 50 {
+		t.Errorf("Expected 50 failed runs of 4 attempts, got %+v", failing)
+	}
+	if got := failing.DelayPercentile(0.99); got != 7*time.Second {
+		t.Errorf("Expected a total delay of 7s, got %v", got)
+	}
+
+	healthy := retryable.SimulateDistribution(opts, 0, 50)
+	if healthy.SuccessRate != 1 || healthy.Attempts[1] != 50 || healthy.DelayPercentile(0.99) != 0 {
+		t.Errorf("Expected 50 immediate successes, got %+v", healthy)
+	}
+}
+
+// TestSimulateDistributionReproducible tests that a seeded source gives the same report, with plausible figures.
+func TestSimulateDistributionReproducible(t *testing.T) {
+	simulate := func() retryable.DistributionReport {
+		return retryable.SimulateDistribution([]retryable.Option{
+			retryable.WithMaxAttempts(3),
+			retryable.WithDelay(100 * time.Millisecond),
+			retryable.WithJitter(0.5),
+			retryable.WithRandSource(rand.NewPCG(1, 2)),
+		}, 0.5, 1000)
+	}
+
+	first, second := simulate(), simulate()
+	if first.SuccessRate != second.SuccessRate || first.DelayPercentile(0.9) != second.DelayPercentile(0.9) {
+		t.Errorf("Expected identical reports, got %+v and %+v", first.SuccessRate, second.SuccessRate)
+	}
+	if first.SuccessRate < 0.8 || first.SuccessRate > 0.95 {
+		t.Errorf("Expected a success rate near 0.875, got %v", first.SuccessRate)
+	}
+	if p40 := first.DelayPercentile(0.4); p40 != 0 {
+		t.Errorf("Expected about half of the runs to succeed at once, got a p40 delay of %v", p40)
+	}
+	if p99 := first.DelayPercentile(0.99); p99 < 100*time.Millisecond || p99 > 300*time.Millisecond {
+		t.Errorf("Expected a p99 delay of two jittered delays at most, got %v", p99)
+	}
+}
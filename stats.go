@@ -0,0 +1,80 @@
+package retryable
+
+import "sync/atomic"
+
+// ProcessRetryStats is a snapshot of the counters aggregated across the process by EnableGlobalStats.
+// They cover the retry loops of the option-based functions, such as RetryWithContext, of Retrier and of
+// RetryHedged, whatever their options; the positional functions, such as Retry, are not counted.
+type ProcessRetryStats struct {
+	// Calls is the number of retry loops started.
+	Calls int64
+	// InFlight is the number of retry loops currently running, a gauge rather than a counter.
+	InFlight int64
+	// Attempts is the number of times the functions were called.
+	Attempts int64
+	// Retries is the number of attempts that followed a failed one, i.e. Attempts minus the first attempts.
+	Retries int64
+	// Successes is the number of loops that ended with OutcomeSuccess.
+	Successes int64
+	// GiveUps is the number of loops that ended with any other outcome, or with a panic of the function.
+	GiveUps int64
+}
+
+// globalStats holds the counters of GlobalStats.
+var globalStats struct {
+	enabled   atomic.Bool
+	calls     atomic.Int64
+	inFlight  atomic.Int64
+	attempts  atomic.Int64
+	retries   atomic.Int64
+	successes atomic.Int64
+	giveUps   atomic.Int64
+}
+
+// EnableGlobalStats starts aggregating the counters returned by GlobalStats over the loops of the
+// option-based functions, Retrier and RetryHedged, but not the positional functions such as Retry. The
+// counters are never reset, so rates come from the difference between two snapshots.
+func EnableGlobalStats() {
+	globalStats.enabled.Store(true)
+}
+
+// DisableGlobalStats stops counting the loops started from then on, keeping the counters as they are.
+func DisableGlobalStats() {
+	globalStats.enabled.Store(false)
+}
+
+// GlobalStats returns a snapshot of the counters aggregated since EnableGlobalStats was first called.
+// The counters are read one at a time while loops may be running, so they are not mutually consistent.
+// It is safe to call concurrently with the retry functions.
+func GlobalStats() ProcessRetryStats {
+	return ProcessRetryStats{
+		Calls:     globalStats.calls.Load(),
+		InFlight:  globalStats.inFlight.Load(),
+		Attempts:  globalStats.attempts.Load(),
+		Retries:   globalStats.retries.Load(),
+		Successes: globalStats.successes.Load(),
+		GiveUps:   globalStats.giveUps.Load(),
+	}
+}
+
+// countStart counts a retry loop starting, reporting whether it is counted.
+func countStart() bool {
+	if !globalStats.enabled.Load() {
+		return false
+	}
+	globalStats.calls.Add(1)
+	globalStats.inFlight.Add(1)
+	return true
+}
+
+// countEnd counts a retry loop counted by countStart ending after the given attempts, successfully or not.
+func countEnd(attempts int, success bool) {
+	globalStats.inFlight.Add(-1)
+	globalStats.attempts.Add(int64(attempts))
+	globalStats.retries.Add(int64(max(attempts-1, 0)))
+	if success {
+		globalStats.successes.Add(1)
+	} else {
+		globalStats.giveUps.Add(1)
+	}
+}
@@ -0,0 +1,99 @@
+package retryable_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestGlobalStats tests that the counters aggregate the loops started while counting is enabled.
+func TestGlobalStats(t *testing.T) {
+	retryable.EnableGlobalStats()
+	defer retryable.DisableGlobalStats()
+	before := retryable.GlobalStats()
+
+	attempts := 0
+	retryable.RetryWithContext(context.Background(), func(context.Context) (int, error) {
+		attempts++
+		if attempts < 3 {
+			return 0, errors.New("transient")
+		}
+		return attempts, nil
+	}, retryable.WithMaxAttempts(5), retryable.WithDelay(time.Millisecond))
+	retryable.RetryWithOptions(failingFn(), retryable.WithMaxAttempts(2), retryable.WithDelay(time.Millisecond))
+
+	after := retryable.GlobalStats()
+	delta := retryable.ProcessRetryStats{
+		Calls:     after.Calls - before.Calls,
+		InFlight:  after.InFlight - before.InFlight,
+		Attempts:  after.Attempts - before.Attempts,
+		Retries:   after.Retries - before.Retries,
+		Successes: after.Successes - before.Successes,
+		GiveUps:   after.GiveUps - before.GiveUps,
+	}
+	expected := retryable.ProcessRetryStats{Calls: 2, Attempts: 5, Retries: 3, Successes: 1, GiveUps: 1}
+	if delta != expected {
+		t.Errorf("Expected the counters to grow by %+v, got %+v", expected, delta)
+	}
+
+	retryable.DisableGlobalStats()
+	retryable.RetryWithOptions(failingFn(), retryable.WithMaxAttempts(1))
+	if stats := retryable.GlobalStats(); stats != after {
+		t.Errorf("Expected the counters to stay at %+v while disabled, got %+v", after, stats)
+	}
+}
+
+// TestGlobalStatsInFlight tests that a running loop is counted in flight until it returns.
+func TestGlobalStatsInFlight(t *testing.T) {
+	retryable.EnableGlobalStats()
+	defer retryable.DisableGlobalStats()
+	before := retryable.GlobalStats().InFlight
+
+	var during int64
+	retryable.RetryWithContext(context.Background(), func(context.Context) (int, error) {
+		during = retryable.GlobalStats().InFlight - before
+		return 0, nil
+	})
+
+	if during != 1 || retryable.GlobalStats().InFlight != before {
+		t.Errorf("Expected 1 loop in flight during the call and none after, got %d", during)
+	}
+}
+
+// TestGlobalStatsPanic tests that a loop whose function panics counts its attempt and is no longer in flight.
+func TestGlobalStatsPanic(t *testing.T) {
+	retryable.EnableGlobalStats()
+	defer retryable.DisableGlobalStats()
+	before := retryable.GlobalStats()
+
+	func() {
+		defer func() { _ = recover() }()
+		retryable.RetryWithContext(context.Background(), func(context.Context) (int, error) {
+			panic("boom")
+		})
+	}()
+
+	after := retryable.GlobalStats()
+	if after.InFlight != before.InFlight || after.GiveUps != before.GiveUps+1 || after.Attempts != before.Attempts+1 {
+		t.Errorf("Expected the panic to count as an attempt and a give-up, not in flight, got %+v then %+v", before, after)
+	}
+}
+
+// TestGlobalStatsHedged tests that RetryHedged is counted.
+func TestGlobalStatsHedged(t *testing.T) {
+	retryable.EnableGlobalStats()
+	defer retryable.DisableGlobalStats()
+	before := retryable.GlobalStats()
+
+	retryable.RetryHedged(context.Background(), func(context.Context) (int, error) {
+		return 1, nil
+	}, time.Hour)
+
+	after := retryable.GlobalStats()
+	if after.Calls != before.Calls+1 || after.Successes != before.Successes+1 || after.InFlight != before.InFlight {
+		t.Errorf("Expected a successful hedged call to be counted, got %+v then %+v", before, after)
+	}
+}
@@ -0,0 +1,90 @@
+package retryable
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// BackoffState is the backoff progression persisted by a BackoffStore between processes.
+type BackoffState struct {
+	// Failures is the number of consecutive failed attempts, zero after a success.
+	Failures int
+	// LastFailure is the time of the last failed attempt.
+	LastFailure time.Time
+}
+
+// BackoffStore persists the backoff progression of an operation across invocations, see WithBackoffStore.
+// Load returns the zero state for an unknown key, and implementations must be safe for concurrent use.
+type BackoffStore interface {
+	Load(key string) (BackoffState, error)
+	Save(key string, state BackoffState) error
+}
+
+// NewMemoryBackoffStore returns a BackoffStore keeping the states in memory, which carries the backoff
+// progression across the calls of a process, or stands in for a persistent store in tests.
+func NewMemoryBackoffStore() BackoffStore {
+	return &memoryBackoffStore{states: make(map[string]BackoffState)}
+}
+
+// memoryBackoffStore is the BackoffStore of NewMemoryBackoffStore.
+type memoryBackoffStore struct {
+	mu     sync.Mutex
+	states map[string]BackoffState
+}
+
+func (m *memoryBackoffStore) Load(key string) (BackoffState, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.states[key], nil
+}
+
+func (m *memoryBackoffStore) Save(key string, state BackoffState) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.states[key] = state
+	return nil
+}
+
+// WithBackoffStore persists the backoff progression of the operation under key, so a call started after a
+// failed one first waits for the rest of the delay it left and continues its progression. The store is not
+// used with WithStatefulBackoff.
+func WithBackoffStore(store BackoffStore, key string) Option {
+	return func(c *config) {
+		c.store = store
+		c.storeKey = key
+	}
+}
+
+// resumeBackoff loads the state persisted by the BackoffStore and waits out the rest of its delay. It
+// returns the number of failures to continue the progression from, the time waited and the error that cut
+// the wait short, if any.
+func (c *config) resumeBackoff(ctx context.Context) (int, time.Duration, error) {
+	state, err := c.store.Load(c.storeKey)
+	now := c.clock.Now()
+	if err != nil || state.Failures <= 0 || state.LastFailure.After(now) {
+		return 0, 0, nil
+	}
+	since := now.Sub(state.LastFailure)
+	if c.streakDecay > 0 && since > c.streakDecay {
+		return 0, 0, nil
+	}
+	wait := c.backoff.Delay(state.Failures) - since
+	if wait <= 0 {
+		return state.Failures, 0, nil
+	}
+	err = c.sleep(ctx, wait)
+	return state.Failures, c.clock.Now().Sub(now), err
+}
+
+// saveBackoff persists the backoff progression, if a BackoffStore is configured and no streak is in use.
+func (c *config) saveBackoff(failures int) {
+	if c.store == nil || c.streak != nil {
+		return
+	}
+	state := BackoffState{Failures: failures}
+	if failures > 0 {
+		state.LastFailure = c.clock.Now()
+	}
+	_ = c.store.Save(c.storeKey, state)
+}
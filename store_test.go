@@ -0,0 +1,98 @@
+package retryable_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestWithBackoffStore tests that a new call resumes the backoff progression persisted by a previous one.
+func TestWithBackoffStore(t *testing.T) {
+	clock := newFakeClock()
+	store := retryable.NewMemoryBackoffStore()
+	run := func(maxAttempts int, fn func(context.Context) (int, error)) retryable.RetryResult[int] {
+		return retryable.RetryWithResult(context.Background(), fn,
+			retryable.WithMaxAttempts(maxAttempts),
+			retryable.WithExponentialBackoff(1*time.Second, 2, 0),
+			retryable.WithClock(clock),
+			retryable.WithBackoffStore(store, "sync"),
+		)
+	}
+	fail := func(context.Context) (int, error) { return 0, errors.New("down") }
+
+	run(3, fail)
+	if state, _ := store.Load("sync"); state.Failures != 3 || !state.LastFailure.Equal(clock.Now()) {
+		t.Fatalf("Expected 3 persisted failures, got %+v", state)
+	}
+
+	res := run(2, fail)
+	if res.SleepTime != 4*time.Second+8*time.Second {
+		t.Errorf("Expected the second call to wait 4s before its first attempt and 8s after it, got %v", res.SleepTime)
+	}
+
+	clock.Advance(1 * time.Hour)
+	res = run(1, func(context.Context) (int, error) { return 1, nil })
+	if state, _ := store.Load("sync"); res.Err != nil || res.SleepTime != 0 || state.Failures != 0 {
+		t.Errorf("Expected a success without waiting to reset the state, got %+v after %v", state, res.SleepTime)
+	}
+}
+
+// corruptStore is a BackoffStore holding an unusable state.
+type corruptStore struct {
+	state retryable.BackoffState
+	err   error
+}
+
+func (s corruptStore) Load(string) (retryable.BackoffState, error) { return s.state, s.err }
+
+func (s corruptStore) Save(string, retryable.BackoffState) error { return errors.New("read-only") }
+
+// TestWithBackoffStoreCorrupt tests that a state that fails to load or makes no sense starts afresh.
+func TestWithBackoffStoreCorrupt(t *testing.T) {
+	clock := newFakeClock()
+	stores := map[string]retryable.BackoffStore{
+		"load error":     corruptStore{state: retryable.BackoffState{Failures: 3, LastFailure: clock.Now()}, err: errors.New("bad json")},
+		"negative count": corruptStore{state: retryable.BackoffState{Failures: -1, LastFailure: clock.Now()}},
+		"future failure": corruptStore{state: retryable.BackoffState{Failures: 3, LastFailure: clock.Now().Add(time.Hour)}},
+	}
+
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			res := retryable.RetryWithResult(context.Background(), func(context.Context) (int, error) { return 1, nil },
+				retryable.WithClock(clock), retryable.WithBackoffStore(store, "sync"))
+			if res.Err != nil || res.SleepTime != 0 {
+				t.Errorf("Expected an immediate success, got %v after waiting %v", res.Err, res.SleepTime)
+			}
+		})
+	}
+}
+
+// recordingStore is a BackoffStore counting its loads and saves.
+type recordingStore struct {
+	loads, saves int
+}
+
+func (s *recordingStore) Load(string) (retryable.BackoffState, error) {
+	s.loads++
+	return retryable.BackoffState{}, nil
+}
+
+func (s *recordingStore) Save(string, retryable.BackoffState) error {
+	s.saves++
+	return nil
+}
+
+// TestWithBackoffStoreStatefulBackoff tests that the store is left alone when the streak of
+// WithStatefulBackoff is in use.
+func TestWithBackoffStoreStatefulBackoff(t *testing.T) {
+	store := &recordingStore{}
+	r := retryable.NewRetrier(retryable.WithStatefulBackoff(), retryable.WithBackoffStore(store, "sync"),
+		retryable.WithMaxAttempts(2), retryable.WithDelay(1*time.Second), retryable.WithClock(newFakeClock()))
+	r.Do(context.Background(), func(context.Context) error { return errors.New("down") })
+	if store.loads != 0 || store.saves != 0 {
+		t.Errorf("Expected the store to be unused, got %d loads and %d saves", store.loads, store.saves)
+	}
+}
@@ -0,0 +1,119 @@
+package retryable
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	// latencyWindow is the number of recent attempt latencies kept by an adaptive timeout.
+	latencyWindow = 100
+
+	// adaptiveTimeoutFactor is the headroom applied to the observed percentile, so that attempts
+	// timing out at the current timeout still let it grow during slow periods.
+	adaptiveTimeoutFactor = 2
+)
+
+// WithAttemptTimeout bounds each attempt with its own timeout, applied to the context passed to the
+// function, while the parent context keeps governing the whole loop.
+func WithAttemptTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		if timeout <= 0 {
+			c.invalid("attempt timeout must be positive, got %v", timeout)
+		}
+		c.attemptTimeout = func() time.Duration { return timeout }
+	}
+}
+
+// WithFinalAttemptOverride calls the final attempt with the context returned by override, instead of
+// bounding it with the attempt timeout, e.g. to select a slow but reliable path. It does not apply with
+// unlimited attempts.
+func WithFinalAttemptOverride(override func(ctx context.Context) context.Context) Option {
+	return func(c *config) {
+		c.finalAttempt = override
+	}
+}
+
+// RetryWithBudgetPerAttempt executes the provided function up to maxAttempts times within totalBudget,
+// bounding each attempt with the remaining budget divided by the remaining attempts, the current one
+// included. The delays come out of the budget too, as with RetryWithDeadline.
+func RetryWithBudgetPerAttempt[T any](ctx context.Context, fn func(ctx context.Context) (T, error), maxAttempts int, totalBudget time.Duration, opts ...Option) (T, error) {
+	c := newConfig(append(append([]Option(nil), opts...), WithMaxAttempts(maxAttempts)))
+	c.budget = totalBudget
+	start := c.clock.Now()
+	res := retryLoop(ctx, func(ctx context.Context) (T, error) {
+		remaining := max(totalBudget-c.clock.Now().Sub(start), 0)
+		ctx, cancel := context.WithTimeout(ctx, remaining/time.Duration(max(RemainingAttempts(ctx)+1, 1)))
+		defer cancel()
+		return fn(ctx)
+	}, c)
+	return res.Value, res.Err
+}
+
+// WithAdaptiveAttemptTimeout bounds each attempt with twice the given percentile of the last 100 latencies
+// observed, clamped to [min, max], and max until one is observed. Build it once to share the latencies.
+func WithAdaptiveAttemptTimeout(percentile float64, min, max time.Duration) Option {
+	tracker := &latencyTracker{}
+	return func(c *config) {
+		if percentile <= 0 || percentile > 1 {
+			c.invalid("timeout percentile must be within (0, 1], got %v", percentile)
+		}
+		if min < 0 || min > max {
+			c.invalid("timeout bounds must satisfy 0 <= min <= max, got min %v and max %v", min, max)
+		}
+		c.latencies = tracker
+		c.attemptTimeout = func() time.Duration {
+			observed, ok := tracker.percentile(percentile)
+			if !ok {
+				return max
+			}
+			return clampDuration(adaptiveTimeoutFactor*observed, min, max)
+		}
+	}
+}
+
+// latencyTracker keeps a ring buffer of recent attempt latencies.
+type latencyTracker struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+// record adds a latency, evicting the oldest one when the window is full.
+func (t *latencyTracker) record(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.samples) < latencyWindow {
+		t.samples = append(t.samples, d)
+		return
+	}
+	t.samples[t.next] = d
+	t.next = (t.next + 1) % latencyWindow
+}
+
+// percentile returns the given percentile of the recorded latencies, or false if there are none.
+func (t *latencyTracker) percentile(p float64) (time.Duration, bool) {
+	t.mu.Lock()
+	sorted := append([]time.Duration(nil), t.samples...)
+	t.mu.Unlock()
+	if len(sorted) == 0 {
+		return 0, false
+	}
+
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return percentileOf(sorted, p), true
+}
+
+// percentileOf returns the given percentile of durations sorted in ascending order, which must not be empty.
+func percentileOf(sorted []time.Duration, p float64) time.Duration {
+	index := int(math.Ceil(p*float64(len(sorted)))) - 1
+	return sorted[min(max(index, 0), len(sorted)-1)]
+}
+
+// clampDuration restricts d to the [low, high] range.
+func clampDuration(d, low, high time.Duration) time.Duration {
+	return min(max(d, low), high)
+}
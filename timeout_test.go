@@ -0,0 +1,205 @@
+package retryable_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestWithAttemptTimeout tests that a slow attempt is cut short and retried.
+func TestWithAttemptTimeout(t *testing.T) {
+	attempts := 0
+	fn := func(ctx context.Context) (int, error) {
+		attempts++
+		if attempts == 1 {
+			<-ctx.Done()
+			return 0, ctx.Err()
+		}
+		return attempts, nil
+	}
+
+	result, err := retryable.RetryWithContext(context.Background(), fn,
+		retryable.WithDelay(1*time.Millisecond),
+		retryable.WithAttemptTimeout(5*time.Millisecond),
+	)
+	if err != nil || result != 2 {
+		t.Errorf("Expected the timed out attempt to be retried, got %v with error %v", result, err)
+	}
+}
+
+// TestWithAdaptiveAttemptTimeout tests that the timeout starts at max and tightens to min for a fast service.
+func TestWithAdaptiveAttemptTimeout(t *testing.T) {
+	r := retryable.NewRetrier(retryable.WithAdaptiveAttemptTimeout(0.95, 10*time.Millisecond, 1*time.Second))
+
+	remaining := func() time.Duration {
+		var timeout time.Duration
+		err := r.Do(context.Background(), func(ctx context.Context) error {
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				return errors.New("missing attempt deadline")
+			}
+			timeout = time.Until(deadline)
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		return timeout
+	}
+
+	if first := remaining(); first < 500*time.Millisecond {
+		t.Errorf("Expected the first attempt to use the max timeout, got %v", first)
+	}
+	for i := 0; i < 20; i++ {
+		remaining()
+	}
+	if last := remaining(); last > 10*time.Millisecond {
+		t.Errorf("Expected the timeout to tighten to the min bound, got %v", last)
+	}
+}
+
+// TestWithAdaptiveAttemptTimeoutConcurrent tests that the latency tracking is shared safely between goroutines.
+func TestWithAdaptiveAttemptTimeoutConcurrent(t *testing.T) {
+	r := retryable.NewRetrier(retryable.WithAdaptiveAttemptTimeout(0.5, 1*time.Millisecond, 1*time.Second))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if err := r.Do(context.Background(), func(context.Context) error { return nil }); err != nil {
+					t.Errorf("Unexpected error: %v", err)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// TestWithAttemptTimeoutClampedToParent tests that the parent deadline clamps a generous attempt timeout.
+func TestWithAttemptTimeoutClampedToParent(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	parentDeadline, _ := ctx.Deadline()
+
+	var attemptDeadline time.Time
+	start := time.Now()
+	_, err := retryable.RetryWithContext(ctx, func(ctx context.Context) (int, error) {
+		attemptDeadline, _ = ctx.Deadline()
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, retryable.WithAttemptTimeout(10*time.Second), retryable.WithDelay(1*time.Millisecond))
+
+	if !attemptDeadline.Equal(parentDeadline) {
+		t.Errorf("Expected the attempt deadline to be the parent deadline %v, got %v", parentDeadline, attemptDeadline)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || time.Since(start) > 1*time.Second {
+		t.Errorf("Expected the loop to end at the parent deadline, got %v after %v", err, time.Since(start))
+	}
+}
+
+// TestRetryWithContextPastDeadline tests that no attempt starts once the parent deadline has passed on the clock.
+func TestRetryWithContextPastDeadline(t *testing.T) {
+	ctx, cancel := context.WithDeadline(context.Background(), time.Now().Add(1*time.Hour))
+	defer cancel()
+	deadline, _ := ctx.Deadline()
+	clock := newFakeClock()
+	clock.Advance(deadline.Sub(clock.Now()))
+
+	called := false
+	_, err := retryable.RetryWithContext(ctx, func(context.Context) (int, error) {
+		called = true
+		return 0, nil
+	}, retryable.WithAttemptTimeout(10*time.Second), retryable.WithClock(clock))
+
+	if called || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected the attempt to be skipped with context.DeadlineExceeded, got called=%v and %v", called, err)
+	}
+}
+
+// slowPathKey is the context key of the endpoint chosen for the final attempt.
+type slowPathKey struct{}
+
+// TestWithFinalAttemptOverride tests that only the final attempt runs with the overridden context and without the attempt timeout.
+func TestWithFinalAttemptOverride(t *testing.T) {
+	var paths []string
+	_, err := retryable.RetryWithContext(context.Background(), func(ctx context.Context) (int, error) {
+		_, bounded := ctx.Deadline()
+		if ctx.Value(slowPathKey{}) != nil {
+			paths = append(paths, "slow")
+			if bounded {
+				return 0, errors.New("unexpected timeout on the final attempt")
+			}
+			return 1, nil
+		}
+		if !bounded {
+			return 0, errors.New("missing attempt timeout")
+		}
+		paths = append(paths, "fast")
+		return 0, errors.New("fast path failed")
+	},
+		retryable.WithMaxAttempts(3),
+		retryable.WithDelay(1*time.Millisecond),
+		retryable.WithAttemptTimeout(1*time.Second),
+		retryable.WithFinalAttemptOverride(func(ctx context.Context) context.Context {
+			return context.WithValue(ctx, slowPathKey{}, true)
+		}),
+	)
+
+	if err != nil || len(paths) != 3 || paths[0] != "fast" || paths[1] != "fast" || paths[2] != "slow" {
+		t.Errorf("Expected two fast attempts then the slow one, got %v with error %v", paths, err)
+	}
+}
+
+// TestRetryWithBudgetPerAttempt tests that the attempts share the budget equally and the call stays within it.
+func TestRetryWithBudgetPerAttempt(t *testing.T) {
+	const budget = 200 * time.Millisecond
+	var timeouts []time.Duration
+	start := time.Now()
+
+	_, err := retryable.RetryWithBudgetPerAttempt(context.Background(), func(ctx context.Context) (int, error) {
+		deadline, _ := ctx.Deadline()
+		timeouts = append(timeouts, time.Until(deadline))
+		<-ctx.Done()
+		return 0, ctx.Err()
+	}, 4, budget, retryable.WithDelay(0))
+
+	if elapsed := time.Since(start); elapsed > budget+50*time.Millisecond {
+		t.Errorf("Expected the call to stay within the budget of %v, took %v", budget, elapsed)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) || len(timeouts) != 4 {
+		t.Fatalf("Expected 4 attempts to time out, got %d with %v", len(timeouts), err)
+	}
+	for i, timeout := range timeouts {
+		if timeout > budget/4 || timeout < budget/4-20*time.Millisecond {
+			t.Errorf("Expected attempt %d to get a quarter of the budget, got %v", i+1, timeout)
+		}
+	}
+}
+
+// TestRetryWithBudgetPerAttemptFastFailure tests that an attempt failing early leaves a larger share to the later ones.
+func TestRetryWithBudgetPerAttemptFastFailure(t *testing.T) {
+	const budget = 300 * time.Millisecond
+	var timeouts []time.Duration
+
+	value, err := retryable.RetryWithBudgetPerAttempt(context.Background(), func(ctx context.Context) (int, error) {
+		deadline, _ := ctx.Deadline()
+		timeouts = append(timeouts, time.Until(deadline))
+		if len(timeouts) == 1 {
+			return 0, errors.New("fast failure")
+		}
+		return len(timeouts), nil
+	}, 3, budget, retryable.WithDelay(0))
+
+	if value != 2 || err != nil {
+		t.Fatalf("Expected success on the second attempt, got %v with %v", value, err)
+	}
+	if timeouts[0] > budget/3 || timeouts[1] <= budget/3 {
+		t.Errorf("Expected the second attempt to get half of the budget left, got %v then %v", timeouts[0], timeouts[1])
+	}
+}
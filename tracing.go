@@ -0,0 +1,43 @@
+package retryable
+
+import "context"
+
+// Tracer starts tracing spans, mirroring the subset of an OpenTelemetry trace.Tracer used by the package:
+//
+//	type otelTracer struct{ t trace.Tracer }
+//
+//	func (o otelTracer) Start(ctx context.Context, name string) (context.Context, retryable.Span) {
+//		ctx, span := o.t.Start(ctx, name)
+//		return ctx, otelSpan{span}
+//	}
+type Tracer interface {
+	// Start creates a span as a child of any span in ctx and returns a context holding it.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a tracing span created by a Tracer.
+type Span interface {
+	// SetAttribute records a key-value attribute on the span.
+	SetAttribute(key string, value any)
+	// RecordError records an error as an event of the span.
+	RecordError(err error)
+	// End completes the span.
+	End()
+}
+
+const (
+	// retrySpanName is the name of the span covering the whole retry operation.
+	retrySpanName = "retryable.retry"
+	// attemptSpanName is the name of the span covering a single attempt.
+	attemptSpanName = "retryable.attempt"
+)
+
+// WithTracer traces the retry operation: a parent span covers the whole loop and records the number
+// of attempts, the outcome and the final error, and a child span per attempt records the attempt number
+// and its error. The context passed to the function carries the attempt span. Spans are always ended,
+// even on early returns or when the function panics.
+func WithTracer(tracer Tracer) Option {
+	return func(c *config) {
+		c.tracer = tracer
+	}
+}
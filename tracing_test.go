@@ -0,0 +1,106 @@
+package retryable_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// recordingTracer is a Tracer keeping every span it creates.
+type recordingTracer struct {
+	mu    sync.Mutex
+	spans []*recordingSpan
+}
+
+type recordingSpan struct {
+	name       string
+	parent     *recordingSpan
+	attributes map[string]any
+	errors     []error
+	ended      bool
+}
+
+type spanKey struct{}
+
+func (t *recordingTracer) Start(ctx context.Context, name string) (context.Context, retryable.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	parent, _ := ctx.Value(spanKey{}).(*recordingSpan)
+	span := &recordingSpan{name: name, parent: parent, attributes: map[string]any{}}
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+func (s *recordingSpan) SetAttribute(key string, value any) { s.attributes[key] = value }
+func (s *recordingSpan) RecordError(err error)              { s.errors = append(s.errors, err) }
+func (s *recordingSpan) End()                               { s.ended = true }
+
+// TestWithTracer tests that a parent span and one child span per attempt are recorded.
+func TestWithTracer(t *testing.T) {
+	tracer := &recordingTracer{}
+	attempts := 0
+	fn := func(ctx context.Context) (int, error) {
+		attempts++
+		if _, ok := ctx.Value(spanKey{}).(*recordingSpan); !ok {
+			t.Errorf("Expected the attempt span in the function context")
+		}
+		if attempts < 2 {
+			return 0, errors.New("temporary error")
+		}
+		return attempts, nil
+	}
+
+	_, err := retryable.RetryWithContext(context.Background(), fn, retryable.WithDelay(1*time.Millisecond), retryable.WithTracer(tracer))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if len(tracer.spans) != 3 {
+		t.Fatalf("Expected 3 spans, got %d", len(tracer.spans))
+	}
+
+	root := tracer.spans[0]
+	if root.parent != nil || root.attributes["retry.attempts"] != 2 || root.attributes["retry.outcome"] != "success" {
+		t.Errorf("Unexpected root span %+v", root)
+	}
+	for i, span := range tracer.spans[1:] {
+		if span.parent != root || span.attributes["retry.attempt"] != i+1 {
+			t.Errorf("Unexpected attempt span %+v", span)
+		}
+	}
+	if len(tracer.spans[1].errors) != 1 || len(tracer.spans[2].errors) != 0 {
+		t.Errorf("Expected only the failed attempt to record an error")
+	}
+	for _, span := range tracer.spans {
+		if !span.ended {
+			t.Errorf("Expected span %s to be ended", span.name)
+		}
+	}
+}
+
+// TestWithTracerPanic tests that spans are ended when the function panics.
+func TestWithTracerPanic(t *testing.T) {
+	tracer := &recordingTracer{}
+	func() {
+		defer func() {
+			if r := recover(); r == nil {
+				t.Errorf("Expected the panic to propagate")
+			}
+		}()
+		retryable.RetryWithContext(context.Background(), func(context.Context) (int, error) {
+			panic("boom")
+		}, retryable.WithTracer(tracer))
+	}()
+
+	if len(tracer.spans) != 2 {
+		t.Fatalf("Expected 2 spans, got %d", len(tracer.spans))
+	}
+	for _, span := range tracer.spans {
+		if !span.ended {
+			t.Errorf("Expected span %s to be ended despite the panic", span.name)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package retryable
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// Transient is a set of common transient conditions, combined with bitwise OR.
+type Transient uint
+
+const (
+	// Timeout matches net.Error timeouts, context.DeadlineExceeded and "timeout"/"timed out" messages.
+	Timeout Transient = 1 << iota
+	// ConnReset matches connections reset or broken by the peer.
+	ConnReset
+	// RateLimited matches rate limiting errors, such as HTTP 429 Too Many Requests.
+	RateLimited
+	// Unavailable matches a temporarily unavailable service, such as HTTP 503 Service Unavailable.
+	Unavailable
+	// Conflict matches conflicting concurrent updates, such as HTTP 409 Conflict.
+	Conflict
+)
+
+// transientDetectors holds the built-in detector of each condition. New conditions only need a new
+// constant and an entry here.
+var transientDetectors = []struct {
+	condition Transient
+	detect    func(err error, msg string) bool
+}{
+	{Timeout, func(err error, msg string) bool {
+		return IsTimeout(err) || errors.Is(err, context.DeadlineExceeded) || containsAny(msg, "timeout", "timed out")
+	}},
+	{ConnReset, func(err error, msg string) bool {
+		return errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) || containsAny(msg, "connection reset", "broken pipe")
+	}},
+	{RateLimited, func(_ error, msg string) bool {
+		return containsWord(msg, "rate limit", "rate limited", "rate limiting", "too many requests", "429")
+	}},
+	{Unavailable, func(_ error, msg string) bool {
+		return containsWord(msg, "unavailable", "503")
+	}},
+	{Conflict, func(_ error, msg string) bool {
+		return containsWord(msg, "conflict", "conflicts", "409")
+	}},
+}
+
+// Match reports whether err matches any of the conditions of the set, by the well-known values and types in
+// its chain or the phrases and status codes in its message, matched as whole words.
+func (t Transient) Match(err error) bool {
+	if err == nil {
+		return false
+	}
+	msg := strings.ToLower(err.Error())
+	for _, d := range transientDetectors {
+		if t&d.condition != 0 && d.detect(err, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+// RetryOnTransient executes the provided function until it succeeds or the maximum number of attempts is
+// reached, retrying only the errors matching one of the given transient conditions, e.g. Timeout|RateLimited.
+// Any other error is returned immediately.
+func RetryOnTransient[T any](fn func() (T, error), maxAttempts int, delay time.Duration, conditions Transient) (T, error) {
+	return RetryWithCustomCheck(fn, maxAttempts, delay, conditions.Match)
+}
+
+// conservativeAttempts is the attempt limit of RetryConservative.
+const conservativeAttempts = 2
+
+// RetryConservative makes at most 2 attempts, retrying only the failures reported by IsPreSendFailure, for
+// operations whose idempotency is unknown.
+func RetryConservative[T any](fn func() (T, error), delay time.Duration) (T, error) {
+	return RetryWithCustomCheck(fn, conservativeAttempts, delay, IsPreSendFailure)
+}
+
+// containsAny reports whether s contains any of the substrings.
+func containsAny(s string, substrings ...string) bool {
+	for _, substring := range substrings {
+		if strings.Contains(s, substring) {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWord reports whether s contains any of the words, or phrases, as a whole: neither preceded nor
+// followed by a letter or a digit.
+func containsWord(s string, words ...string) bool {
+	for _, word := range words {
+		for i := 0; i+len(word) <= len(s); {
+			j := strings.Index(s[i:], word)
+			if j < 0 {
+				break
+			}
+			start, end := i+j, i+j+len(word)
+			if (start == 0 || !isWordByte(s[start-1])) && (end == len(s) || !isWordByte(s[end])) {
+				return true
+			}
+			i = start + 1
+		}
+	}
+	return false
+}
+
+// isWordByte reports whether b is an ASCII letter or digit.
+func isWordByte(b byte) bool {
+	return 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z' || '0' <= b && b <= '9'
+}
@@ -0,0 +1,114 @@
+package retryable_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestTransientMatch tests the built-in detector of each condition.
+func TestTransientMatch(t *testing.T) {
+	tests := []struct {
+		condition retryable.Transient
+		err       error
+	}{
+		{retryable.Timeout, fakeNetError{timeout: true}},
+		{retryable.Timeout, fmt.Errorf("call: %w", context.DeadlineExceeded)},
+		{retryable.Timeout, errors.New("request Timed Out")},
+		{retryable.ConnReset, fmt.Errorf("read: %w", syscall.ECONNRESET)},
+		{retryable.ConnReset, errors.New("write: broken pipe")},
+		{retryable.RateLimited, errors.New("HTTP 429 Too Many Requests")},
+		{retryable.Unavailable, errors.New("service unavailable")},
+		{retryable.Conflict, errors.New("409 conflict")},
+	}
+
+	for _, tt := range tests {
+		if !tt.condition.Match(tt.err) {
+			t.Errorf("Expected %v to match condition %d", tt.err, tt.condition)
+		}
+	}
+	if (retryable.RateLimited | retryable.Conflict).Match(errors.New("i/o timeout")) {
+		t.Errorf("Expected a timeout to not match unrelated conditions")
+	}
+	if retryable.Timeout.Match(nil) {
+		t.Errorf("Expected nil to never match")
+	}
+}
+
+// TestTransientMatchWholeWords tests that status codes and phrases embedded in other words do not match.
+func TestTransientMatchWholeWords(t *testing.T) {
+	conditions := retryable.RateLimited | retryable.Unavailable | retryable.Conflict
+	for _, msg := range []string{
+		"order 14090 not found",
+		"user 4291 deleted",
+		"invoice 15030 rejected",
+		"nonconflicting update ignored",
+		"deunavailable flag unset",
+	} {
+		if conditions.Match(errors.New(msg)) {
+			t.Errorf("Expected %q not to match", msg)
+		}
+	}
+	for _, msg := range []string{"status 503.", "(429) slow down", "rate limited by upstream", "edit conflicts: retry"} {
+		if !conditions.Match(errors.New(msg)) {
+			t.Errorf("Expected %q to match", msg)
+		}
+	}
+}
+
+// TestRetryOnTransient tests that only the selected transient conditions are retried.
+func TestRetryOnTransient(t *testing.T) {
+	attempts := 0
+	fn := func() (int, error) {
+		attempts++
+		switch attempts {
+		case 1:
+			return 0, errors.New("too many requests")
+		case 2:
+			return 0, errors.New("i/o timeout")
+		}
+		return 0, errors.New("permission denied")
+	}
+
+	_, err := retryable.RetryOnTransient(fn, 5, 1*time.Millisecond, retryable.Timeout|retryable.RateLimited)
+	if err == nil || err.Error() != "permission denied" || attempts != 3 {
+		t.Errorf("Expected to stop on the non-transient error after 3 attempts, got %v after %d attempts", err, attempts)
+	}
+}
+
+// TestRetryConservative tests that only pre-send failures are retried, at most once.
+func TestRetryConservative(t *testing.T) {
+	attempts := 0
+	_, err := retryable.RetryConservative(func() (int, error) {
+		attempts++
+		return 0, fmt.Errorf("dial: %w", syscall.ECONNREFUSED)
+	}, 1*time.Millisecond)
+	if attempts != 2 || !errors.Is(err, syscall.ECONNREFUSED) {
+		t.Errorf("Expected 2 attempts on a refused connection, got %d and %v", attempts, err)
+	}
+
+	attempts = 0
+	_, err = retryable.RetryConservative(func() (int, error) {
+		attempts++
+		return 0, fakeNetError{timeout: true}
+	}, 1*time.Millisecond)
+	if attempts != 1 || !errors.Is(err, retryable.ErrNonRetryable) {
+		t.Errorf("Expected a timeout not to be retried, got %d attempts and %v", attempts, err)
+	}
+}
+
+// TestRetryConservativeNoFinalDelay tests that no delay follows the second and final attempt.
+func TestRetryConservativeNoFinalDelay(t *testing.T) {
+	start := time.Now()
+	retryable.RetryConservative(func() (int, error) {
+		return 0, fmt.Errorf("dial: %w", syscall.ECONNREFUSED)
+	}, 50*time.Millisecond)
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond || elapsed >= 100*time.Millisecond {
+		t.Errorf("Expected a single delay of 50ms between the attempts, took %v", elapsed)
+	}
+}
@@ -0,0 +1,81 @@
+package retryable
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// ErrInvalidConfig is wrapped by every error returned by ValidateConfig.
+var ErrInvalidConfig = errors.New("retryable: invalid configuration")
+
+// validator is implemented by the backoff strategies of the package that can check their parameters.
+type validator interface {
+	validate() error
+}
+
+// ValidateConfig returns an error wrapping ErrInvalidConfig for every invalid setting of the options, as
+// documented by each of them. The retry functions do not validate their options outside strict mode.
+func ValidateConfig(opts ...Option) error {
+	return newConfig(opts).validate()
+}
+
+// validate returns the configuration problems reported by ValidateConfig.
+func (c *config) validate() error {
+	errs := append([]error(nil), c.problems...)
+	if c.maxAttempts < 1 {
+		errs = append(errs, invalidConfig("max attempts must be at least 1, got %d", c.maxAttempts))
+	}
+	if v, ok := c.backoff.(validator); ok {
+		if err := v.validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if c.jitter < 0 || c.jitter > 1 {
+		errs = append(errs, invalidConfig("jitter factor must be within [0, 1], got %v", c.jitter))
+	}
+	return errors.Join(errs...)
+}
+
+// strictMode is set by SetStrictMode.
+var strictMode atomic.Bool
+
+// SetStrictMode makes the option-based functions return OutcomeInvalidConfig without calling the function
+// when the options are invalid, or set up a jitter without delay or a budget or deadline shorter than the
+// first delay. It is disabled by default.
+func SetStrictMode(enabled bool) {
+	strictMode.Store(enabled)
+}
+
+// strictCheck returns the problems found by strict mode for a call with the given context, if enabled.
+func (c *config) strictCheck(ctx context.Context) error {
+	if !strictMode.Load() {
+		return nil
+	}
+	errs := []error{c.validate()}
+	first := c.backoff.Delay(1)
+	if c.jitter > 0 && first == 0 {
+		errs = append(errs, invalidConfig("jitter factor %v has no effect with a zero delay", c.jitter))
+	}
+	if c.budget > 0 && first > c.budget {
+		errs = append(errs, invalidConfig("time budget %v is shorter than the first delay %v", c.budget, first))
+	}
+	if deadline, ok := ctx.Deadline(); ok && first > 0 {
+		if remaining := deadline.Sub(c.clock.Now()); remaining < first {
+			errs = append(errs, invalidConfig("context deadline in %v ends before the first delay %v", remaining.Round(time.Millisecond), first))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// invalid records a configuration problem reported by ValidateConfig.
+func (c *config) invalid(format string, args ...interface{}) {
+	c.problems = append(c.problems, invalidConfig(format, args...))
+}
+
+// invalidConfig builds an error wrapping ErrInvalidConfig.
+func invalidConfig(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidConfig, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,121 @@
+package retryable_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/raniellyferreira/go-retryable"
+)
+
+// TestValidateConfigValid tests that a sound configuration passes validation.
+func TestValidateConfigValid(t *testing.T) {
+	err := retryable.ValidateConfig(
+		retryable.WithMaxAttempts(5),
+		retryable.WithExponentialBackoff(100*time.Millisecond, 2, 5*time.Second),
+		retryable.WithJitter(0.2),
+		retryable.WithAttemptTimeout(1*time.Second),
+	)
+	if err != nil {
+		t.Errorf("Expected a valid configuration, got %v", err)
+	}
+	if err := retryable.ValidateConfig(); err != nil {
+		t.Errorf("Expected the defaults to be valid, got %v", err)
+	}
+}
+
+// TestValidateConfigInvalid tests each invalid setting.
+func TestValidateConfigInvalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		opt    retryable.Option
+		reason string
+	}{
+		{"zero attempts", retryable.WithMaxAttempts(0), "max attempts"},
+		{"negative delay", retryable.WithDelay(-1 * time.Second), "delay must not be negative"},
+		{"negative base", retryable.WithExponentialBackoff(-1*time.Second, 2, 0), "base delay must not be negative"},
+		{"shrinking multiplier", retryable.WithExponentialBackoff(1*time.Second, 0.5, 0), "multiplier"},
+		{"min greater than max delay", retryable.WithExponentialBackoff(2*time.Second, 2, 1*time.Second), "greater than max delay"},
+		{"jitter above 1", retryable.WithJitter(1.5), "jitter factor"},
+		{"negative jitter", retryable.WithJitter(-0.1), "jitter factor"},
+		{"zero attempt timeout", retryable.WithAttemptTimeout(0), "attempt timeout"},
+		{"percentile out of range", retryable.WithAdaptiveAttemptTimeout(1.5, 0, time.Second), "percentile"},
+		{"inverted timeout bounds", retryable.WithAdaptiveAttemptTimeout(0.9, 2*time.Second, time.Second), "timeout bounds"},
+		{"zero stalls", retryable.WithNoProgressDetection(func(a, b int) bool { return a == b }, 0), "max stalls"},
+		{"zero successes before reset", retryable.WithResetAfterSuccesses(0), "successes before a reset"},
+		{"zero breaker threshold", retryable.WithCircuitBreaker(retryable.NewCircuitBreaker(retryable.CircuitBreakerConfig{Cooldown: time.Second})), "failure threshold"},
+		{"zero breaker cooldown", retryable.WithCircuitBreaker(retryable.NewCircuitBreaker(retryable.CircuitBreakerConfig{FailureThreshold: 1})), "cooldown"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := retryable.ValidateConfig(tt.opt)
+			if !errors.Is(err, retryable.ErrInvalidConfig) || !strings.Contains(err.Error(), tt.reason) {
+				t.Errorf("Expected an invalid configuration error about %q, got %v", tt.reason, err)
+			}
+		})
+	}
+}
+
+// TestValidateConfigMultipleErrors tests that every problem is reported at once.
+func TestValidateConfigMultipleErrors(t *testing.T) {
+	err := retryable.ValidateConfig(retryable.WithMaxAttempts(-1), retryable.WithJitter(2))
+	if err == nil || !strings.Contains(err.Error(), "max attempts") || !strings.Contains(err.Error(), "jitter") {
+		t.Errorf("Expected both problems to be reported, got %v", err)
+	}
+}
+
+// TestValidateConfigConcurrencyLimit tests that an unbuffered semaphore is rejected.
+func TestValidateConfigConcurrencyLimit(t *testing.T) {
+	if err := retryable.ValidateConfig(retryable.WithConcurrencyLimit(make(chan struct{}))); !errors.Is(err, retryable.ErrInvalidConfig) {
+		t.Errorf("Expected ErrInvalidConfig, got %v", err)
+	}
+	if err := retryable.ValidateConfig(retryable.WithConcurrencyLimit(make(chan struct{}, 4))); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+// TestSetStrictMode tests that strict mode rejects the invalid and ambiguous setups at call time, without calling the function.
+func TestSetStrictMode(t *testing.T) {
+	retryable.SetStrictMode(true)
+	t.Cleanup(func() { retryable.SetStrictMode(false) })
+
+	shortCtx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		opts   []retryable.Option
+		reason string
+	}{
+		{"invalid option", context.Background(), []retryable.Option{retryable.WithJitter(2)}, "jitter factor must be within"},
+		{"cap below base", context.Background(), []retryable.Option{retryable.WithExponentialBackoff(2*time.Second, 2, 1*time.Second)}, "greater than max delay"},
+		{"jitter with zero delay", context.Background(), []retryable.Option{retryable.WithDelay(0), retryable.WithJitter(0.5)}, "no effect with a zero delay"},
+		{"deadline shorter than a delay", shortCtx, []retryable.Option{retryable.WithDelay(1 * time.Minute)}, "ends before the first delay"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			called := false
+			res := retryable.RetryWithResult(tt.ctx, func(context.Context) (int, error) {
+				called = true
+				return 1, nil
+			}, tt.opts...)
+			if called || res.Outcome != retryable.OutcomeInvalidConfig || !errors.Is(res.Err, retryable.ErrInvalidConfig) || !strings.Contains(res.Err.Error(), tt.reason) {
+				t.Errorf("Expected a configuration error about %q without any attempt, got %v", tt.reason, res.Err)
+			}
+		})
+	}
+
+	_, err := retryable.RetryWithDeadline(func() (int, error) { return 1, nil }, 1*time.Second, retryable.WithDelay(2*time.Second))
+	if !errors.Is(err, retryable.ErrInvalidConfig) || !strings.Contains(err.Error(), "shorter than the first delay") {
+		t.Errorf("Expected the budget to be rejected, got %v", err)
+	}
+
+	retryable.SetStrictMode(false)
+	if _, err := retryable.RetryWithOptions(func() (int, error) { return 1, nil }, retryable.WithDelay(0), retryable.WithJitter(0.5)); err != nil {
+		t.Errorf("Expected the ambiguous setup to be accepted outside strict mode, got %v", err)
+	}
+}